@@ -0,0 +1,216 @@
+// Package analytics counts views of blogs and projects. Views are
+// deduplicated per visitor per day and buffered in memory, then written to
+// the database in batches so reading a page never waits on a write.
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"avions-club/backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// flushThreshold is the number of buffered counters that triggers a flush
+// before the next tick
+const flushThreshold = 500
+
+// botPattern matches the user agents of crawlers, link previewers, uptime
+// monitors and command line clients
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|embedly|preview|` +
+	`curl|wget|python|go-http-client|java/|okhttp|axios|node-fetch|httpclient|libwww|` +
+	`headless|phantomjs|lighthouse|pingdom|uptime|monitor|check_http`)
+
+// IsBot reports whether a user agent belongs to an automated client rather
+// than a person. Requests without a user agent count as bots.
+func IsBot(userAgent string) bool {
+	return userAgent == "" || botPattern.MatchString(userAgent)
+}
+
+// maxSeen bounds how many visitor and content pairs are remembered per day.
+// Once it is reached, views from visitors not seen yet that day are not
+// counted, so a flood of made-up visitors cannot exhaust memory.
+const maxSeen = 200_000
+
+// visitorKey returns the key of the visitor hash: VISITOR_HASH_KEY, or
+// JWT_SECRET when it is not set
+func visitorKey() []byte {
+	if key := os.Getenv("VISITOR_HASH_KEY"); key != "" {
+		return []byte(key)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// VisitorHash identifies a visitor by their IP address and user agent
+// without storing either. It is keyed with a server secret, so the IP
+// address cannot be recovered by hashing every possible address.
+func VisitorHash(ip, userAgent string) string {
+	mac := hmac.New(sha256.New, visitorKey())
+	mac.Write([]byte(ip + "\x00" + userAgent))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// counter identifies the daily view count of one blog or project
+type counter struct {
+	contentType string
+	contentID   uuid.UUID
+	day         time.Time
+}
+
+// Tracker buffers views until they are flushed to the database
+type Tracker struct {
+	db *gorm.DB
+
+	mu      sync.Mutex
+	day     time.Time
+	seen    map[string]struct{}
+	pending map[counter]int64
+	flushCh chan struct{}
+}
+
+// NewTracker returns a tracker writing to db. Call Run to flush it.
+func NewTracker(db *gorm.DB) *Tracker {
+	return &Tracker{
+		db:      db,
+		seen:    make(map[string]struct{}),
+		pending: make(map[counter]int64),
+		flushCh: make(chan struct{}, 1),
+	}
+}
+
+// RecordView counts a view unless the visitor is a bot or already viewed the
+// same content today. It reports whether the view was counted.
+func (t *Tracker) RecordView(contentType string, contentID uuid.UUID, ip, userAgent string) bool {
+	if IsBot(userAgent) {
+		return false
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	key := contentType + "/" + contentID.String() + "/" + VisitorHash(ip, userAgent)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Visitors are only remembered for the current day
+	if !day.Equal(t.day) {
+		t.day = day
+		t.seen = make(map[string]struct{})
+	}
+	if _, ok := t.seen[key]; ok || len(t.seen) >= maxSeen {
+		return false
+	}
+	t.seen[key] = struct{}{}
+	t.pending[counter{contentType, contentID, day}]++
+
+	if len(t.pending) >= flushThreshold {
+		select {
+		case t.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// Pending returns the views of a blog or project that are not flushed yet
+func (t *Tracker) Pending(contentType string, contentID uuid.UUID) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var views int64
+	for c, n := range t.pending {
+		if c.contentType == contentType && c.contentID == contentID {
+			views += n
+		}
+	}
+	return views
+}
+
+// Flush writes the buffered views to the database. Views that could not be
+// written are kept for the next flush.
+func (t *Tracker) Flush() error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[counter]int64)
+	t.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	rows := make([]models.ContentView, 0, len(pending))
+	for c, n := range pending {
+		rows = append(rows, models.ContentView{
+			ContentType: c.contentType,
+			ContentID:   c.contentID,
+			Day:         c.day,
+			Views:       n,
+		})
+	}
+	err := t.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "content_type"}, {Name: "content_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"views": gorm.Expr("content_views.views + excluded.views"),
+		}),
+	}).CreateInBatches(&rows, 200).Error
+	if err != nil {
+		t.mu.Lock()
+		for c, n := range pending {
+			t.pending[c] += n
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the tracker every interval, or sooner when the buffer fills
+// up. It never returns.
+func (t *Tracker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.flushCh:
+		}
+		if err := t.Flush(); err != nil {
+			log.Printf("Error flushing view counts: %v", err)
+		}
+	}
+}
+
+// defaultTracker is the tracker used by the package-level functions
+var defaultTracker *Tracker
+
+// Start creates the default tracker and flushes it in the background every
+// interval
+func Start(db *gorm.DB, interval time.Duration) {
+	defaultTracker = NewTracker(db)
+	go defaultTracker.Run(interval)
+}
+
+// RecordView counts a view with the default tracker. Views are dropped when
+// the tracker has not been started.
+func RecordView(contentType string, contentID uuid.UUID, ip, userAgent string) bool {
+	if defaultTracker == nil {
+		return false
+	}
+	return defaultTracker.RecordView(contentType, contentID, ip, userAgent)
+}
+
+// Pending returns the unflushed views of a blog or project in the default
+// tracker
+func Pending(contentType string, contentID uuid.UUID) int64 {
+	if defaultTracker == nil {
+		return 0
+	}
+	return defaultTracker.Pending(contentType, contentID)
+}
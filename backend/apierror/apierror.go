@@ -0,0 +1,102 @@
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes returned in the error envelope
+const (
+	CodeBadRequest   = "bad_request"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeRateLimited  = "rate_limited"
+
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+)
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is the body of every error response
+type Error struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	Details any          `json:"details,omitempty"`
+}
+
+// Response wraps an Error so clients always find it under "error"
+type Response struct {
+	Error Error `json:"error"`
+}
+
+// Respond aborts the request and writes the error envelope
+func Respond(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, Response{Error: Error{Code: code, Message: message}})
+}
+
+// Validation aborts the request with a 422 listing the rejected fields
+func Validation(c *gin.Context, fields []FieldError) {
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, Response{Error: Error{
+		Code:    CodeValidation,
+		Message: "Request validation failed",
+		Fields:  fields,
+	}})
+}
+
+// BadRequest responds with 400 Bad Request
+func BadRequest(c *gin.Context, message string) {
+	Respond(c, http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Unauthorized responds with 401 Unauthorized
+func Unauthorized(c *gin.Context, message string) {
+	Respond(c, http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden responds with 403 Forbidden
+func Forbidden(c *gin.Context, message string) {
+	Respond(c, http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound responds with 404 Not Found
+func NotFound(c *gin.Context, message string) {
+	Respond(c, http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict responds with 409 Conflict
+func Conflict(c *gin.Context, message string) {
+	Respond(c, http.StatusConflict, CodeConflict, message)
+}
+
+// ConflictWithDetails responds with 409 Conflict and describes what conflicted
+func ConflictWithDetails(c *gin.Context, message string, details any) {
+	c.AbortWithStatusJSON(http.StatusConflict, Response{Error: Error{
+		Code:    CodeConflict,
+		Message: message,
+		Details: details,
+	}})
+}
+
+// Internal responds with 500 Internal Server Error
+func Internal(c *gin.Context, message string) {
+	Respond(c, http.StatusInternalServerError, CodeInternal, message)
+}
+
+// Unavailable responds with 503 Service Unavailable, for features that are
+// not configured on this server
+func Unavailable(c *gin.Context, message string) {
+	Respond(c, http.StatusServiceUnavailable, CodeUnavailable, message)
+}
@@ -59,13 +59,72 @@ func InitDB() {
 	// Auto Migrate the schemas
 	err = gormDB.AutoMigrate(
 		&models.Member{},
+		&models.MemberPosition{},
+		&models.MemberContact{},
+		&models.MemberCertification{},
 		&models.Project{},
 		&models.Blog{},
+		&models.BlogAuthor{},
+		&models.Application{},
+		&models.User{},
+		&models.Event{},
+		&models.EventRSVP{},
+		&models.FlightLog{},
+		&models.TelemetryLog{},
+		&models.InventoryItem{},
+		&models.InventoryCheckout{},
+		&models.Sponsor{},
+		&models.Competition{},
+		&models.Achievement{},
+		&models.Album{},
+		&models.Photo{},
+		&models.Comment{},
+		&models.ContentView{},
+		&models.Reaction{},
+		&models.Subscriber{},
+		&models.DigestRun{},
+		&models.ContactMessage{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.AuditLog{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Backfill the byline for blogs created before co-authors existed
+	err = gormDB.Exec(`
+		INSERT INTO blog_authors (blog_id, member_id, position)
+		SELECT b.id, b.author_id, 0 FROM blogs b
+		WHERE NOT EXISTS (SELECT 1 FROM blog_authors ba WHERE ba.blog_id = b.id)`).Error
+	if err != nil {
+		log.Fatal("Failed to backfill blog authors:", err)
+	}
+
+	// Start the position history of members created before it was tracked
+	err = gormDB.Exec(`
+		INSERT INTO member_positions (id, member_id, title, started_at)
+		SELECT uuid_generate_v4(), m.id, m.position, m.joined_at FROM members m
+		WHERE NOT EXISTS (SELECT 1 FROM member_positions mp WHERE mp.member_id = m.id)`).Error
+	if err != nil {
+		log.Fatal("Failed to backfill member positions:", err)
+	}
+
+	// Keep the audit log append-only, whatever the application does
+	err = gormDB.Exec(`
+		CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+		CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_logs
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();`).Error
+	if err != nil {
+		log.Fatal("Failed to protect the audit log:", err)
+	}
+
 	DB = gormDB
 	log.Println("Database connection and migrations completed")
 }
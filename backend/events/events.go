@@ -0,0 +1,461 @@
+// Package events is an in-process event bus backed by a transactional
+// outbox. Handlers publish an event in the same database transaction as the
+// change it describes, so the event exists exactly when the change does. A
+// relay then hands stored events to the subscribers in the background and
+// retries the ones that fail, including after a restart.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"avions-club/backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Domain events. Blogs have no drafts, so a blog is published when it is
+// created.
+const (
+	BlogPublished   = "blog.published"
+	BlogUpdated     = "blog.updated"
+	BlogDeleted     = "blog.deleted"
+	BlogRestored    = "blog.restored"
+	BlogPurged      = "blog.purged"
+	ProjectCreated  = "project.created"
+	ProjectUpdated  = "project.updated"
+	ProjectDeleted  = "project.deleted"
+	ProjectRestored = "project.restored"
+	ProjectPurged   = "project.purged"
+	MemberCreated   = "member.created"
+	MemberUpdated   = "member.updated"
+	MemberDeleted   = "member.deleted"
+	MemberRestored  = "member.restored"
+	MemberPurged    = "member.purged"
+	EventCreated    = "event.created"
+	EventUpdated    = "event.updated"
+	EventDeleted    = "event.deleted"
+	EventRestored   = "event.restored"
+	EventPurged     = "event.purged"
+	FileUploaded    = "file.uploaded"
+	FileDeleted     = "file.deleted"
+)
+
+// Names lists every domain event
+var Names = []string{
+	BlogPublished, BlogUpdated, BlogDeleted, BlogRestored, BlogPurged,
+	ProjectCreated, ProjectUpdated, ProjectDeleted, ProjectRestored, ProjectPurged,
+	MemberCreated, MemberUpdated, MemberDeleted, MemberRestored, MemberPurged,
+	EventCreated, EventUpdated, EventDeleted, EventRestored, EventPurged,
+	FileUploaded, FileDeleted,
+}
+
+// All subscribes to every event
+const All = "*"
+
+const (
+	// MaxAttempts is how often an event is handed to failing subscribers
+	// before it is marked failed
+	MaxAttempts = 10
+
+	// baseDelay is the wait after the first failed attempt; it doubles with
+	// every further attempt up to maxDelay
+	baseDelay = 10 * time.Second
+	maxDelay  = time.Hour
+
+	// claimLease keeps a claimed event from being claimed again while its
+	// subscribers run
+	claimLease = 5 * time.Minute
+
+	// batchSize is how many due events are claimed at once
+	batchSize = 50
+
+	// retention is how long processed events are kept
+	retention = 7 * 24 * time.Hour
+)
+
+// MemberData is the payload of the member events. It holds only what the
+// public member list shows, so contacts and profile details never reach the
+// outbox or webhook receivers.
+type MemberData struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Position string    `json:"position"`
+	Status   string    `json:"status"`
+	ImageURL string    `json:"imageUrl"`
+}
+
+// NewMemberData returns the payload of a member event
+func NewMemberData(member *models.Member) MemberData {
+	return MemberData{
+		ID:       member.ID,
+		Name:     member.Name,
+		Position: member.Position,
+		Status:   member.Status,
+		ImageURL: member.ImageURL,
+	}
+}
+
+// AuthorData names an author of a blog in BlogData
+type AuthorData struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// BlogData is the payload of the blog events, with the byline reduced to
+// the ID and name of each author
+type BlogData struct {
+	ID          uuid.UUID    `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	MarkdownURL string       `json:"markdownUrl"`
+	CoverURL    string       `json:"coverUrl"`
+	Tags        []string     `json:"tags"`
+	Authors     []AuthorData `json:"authors"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// NewBlogData returns the payload of a blog event. Authors come from the
+// preloaded byline; without it the primary author is listed alone.
+func NewBlogData(blog *models.Blog) BlogData {
+	data := BlogData{
+		ID:          blog.ID,
+		Title:       blog.Title,
+		Description: blog.Description,
+		MarkdownURL: blog.MarkdownURL,
+		CoverURL:    blog.CoverURL,
+		Tags:        blog.Tags,
+		Authors:     []AuthorData{},
+		CreatedAt:   blog.CreatedAt,
+		UpdatedAt:   blog.UpdatedAt,
+	}
+	for _, author := range blog.Authors {
+		data.Authors = append(data.Authors, AuthorData{ID: author.MemberID, Name: author.Member.Name})
+	}
+	if len(data.Authors) == 0 && blog.AuthorID != uuid.Nil {
+		data.Authors = append(data.Authors, AuthorData{ID: blog.AuthorID, Name: blog.Author.Name})
+	}
+	return data
+}
+
+// ProjectData is the payload of the project events, without telemetry
+type ProjectData struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	MarkdownURL string    `json:"markdownUrl"`
+	ImageURL    string    `json:"imageUrl"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// NewProjectData returns the payload of a project event
+func NewProjectData(project *models.Project) ProjectData {
+	return ProjectData{
+		ID:          project.ID,
+		Title:       project.Title,
+		Description: project.Description,
+		MarkdownURL: project.MarkdownURL,
+		ImageURL:    project.ImageURL,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}
+}
+
+// EventData is the payload of the event events, with linked projects
+// reduced to their IDs and without RSVP counts
+type EventData struct {
+	ID          uuid.UUID   `json:"id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Kind        string      `json:"kind"`
+	Location    string      `json:"location"`
+	Status      string      `json:"status"`
+	Tags        []string    `json:"tags"`
+	ImageURL    string      `json:"imageUrl"`
+	StartsAt    time.Time   `json:"startsAt"`
+	EndsAt      time.Time   `json:"endsAt"`
+	Capacity    *int        `json:"capacity"`
+	ProjectIDs  []uuid.UUID `json:"projectIds"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// NewEventData returns the payload of an event event
+func NewEventData(event *models.Event) EventData {
+	data := EventData{
+		ID:          event.ID,
+		Title:       event.Title,
+		Description: event.Description,
+		Kind:        event.Kind,
+		Location:    event.Location,
+		Status:      event.Status,
+		Tags:        event.Tags,
+		ImageURL:    event.ImageURL,
+		StartsAt:    event.StartsAt,
+		EndsAt:      event.EndsAt,
+		Capacity:    event.Capacity,
+		ProjectIDs:  []uuid.UUID{},
+		CreatedAt:   event.CreatedAt,
+		UpdatedAt:   event.UpdatedAt,
+	}
+	for _, project := range event.Projects {
+		data.ProjectIDs = append(data.ProjectIDs, project.ID)
+	}
+	return data
+}
+
+// FileData is the payload of FileUploaded and FileDeleted
+type FileData struct {
+	Bucket   string `json:"bucket"`
+	Filename string `json:"filename"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Event is a stored event handed to a subscriber. Payload is the JSON of
+// the data it was published with.
+type Event struct {
+	ID         uuid.UUID
+	Name       string
+	OccurredAt time.Time
+	Payload    json.RawMessage
+}
+
+// Decode unmarshals the payload of an event into v
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler handles an event. Handlers may see an event more than once, e.g.
+// when the process stops before the outcome is recorded, so they must be
+// idempotent.
+type Handler func(Event) error
+
+// subscriber is a named handler for the events matching a pattern
+type subscriber struct {
+	name    string
+	pattern string
+	handle  Handler
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []subscriber
+	wakeCh      = make(chan struct{}, 1)
+)
+
+// Subscribe registers a handler under a unique name for the events matching
+// pattern: an event name, a prefix such as "blog.*", or All. The name is
+// recorded on each event the handler completes, so it must stay stable
+// across releases.
+func Subscribe(name, pattern string, handle Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	if slices.ContainsFunc(subscribers, func(s subscriber) bool { return s.name == name }) {
+		panic(fmt.Sprintf("events: subscriber %q registered twice", name))
+	}
+	subscribers = append(subscribers, subscriber{name: name, pattern: pattern, handle: handle})
+}
+
+// matches reports whether an event name matches a subscription pattern
+func matches(pattern, name string) bool {
+	if pattern == All || pattern == name {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasPrefix(name, prefix)
+}
+
+// Publish stores an event in the outbox. Pass the transaction making the
+// change so the event is only kept if the change is committed.
+func Publish(tx *gorm.DB, name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := tx.Create(&models.OutboxEvent{
+		Name:          name,
+		Payload:       payload,
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+		Completed:     []string{},
+	}).Error; err != nil {
+		return err
+	}
+	wake()
+	return nil
+}
+
+// wake makes the relay look for due events without waiting for the next
+// poll. An event published in a transaction that is still open is picked up
+// by the poll after its commit.
+func wake() {
+	select {
+	case wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// backoff returns how long to wait before retrying after the given number
+// of failed attempts
+func backoff(attempts int) time.Duration {
+	if attempts >= 20 {
+		return maxDelay
+	}
+	return min(baseDelay<<(attempts-1), maxDelay)
+}
+
+// claim picks the events that are due and pushes their next attempt past the
+// lease, so other instances skip them while their subscribers run
+func claim(db *gorm.DB) ([]models.OutboxEvent, error) {
+	var due []models.OutboxEvent
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxStatusPending, now).
+			Order("created_at").
+			Limit(batchSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(due))
+		for i, event := range due {
+			ids[i] = event.ID
+		}
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	return due, err
+}
+
+// handle runs one subscriber, turning a panic into an error so it cannot
+// take the relay down
+func handle(s subscriber, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.handle(event)
+}
+
+// process hands a claimed event to every matching subscriber that has not
+// handled it yet and records the outcome
+func process(db *gorm.DB, stored models.OutboxEvent) {
+	event := Event{ID: stored.ID, Name: stored.Name, OccurredAt: stored.CreatedAt, Payload: stored.Payload}
+	completed := slices.Clone(stored.Completed)
+
+	mu.RLock()
+	current := slices.Clone(subscribers)
+	mu.RUnlock()
+
+	var errs []error
+	for _, s := range current {
+		if !matches(s.pattern, event.Name) || slices.Contains(completed, s.name) {
+			continue
+		}
+		if err := handle(s, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			continue
+		}
+		completed = append(completed, s.name)
+	}
+
+	// Completed is stored as JSON; a map update skips the model's serializer
+	completedJSON, err := json.Marshal(completed)
+	if err != nil {
+		log.Printf("Error recording event %s: %v", event.ID, err)
+		return
+	}
+	now := time.Now()
+	attempts := stored.Attempts + 1
+	updates := map[string]any{
+		"attempts":   attempts,
+		"completed":  string(completedJSON),
+		"last_error": "",
+	}
+	switch err := errors.Join(errs...); {
+	case err == nil:
+		updates["status"] = models.OutboxStatusProcessed
+		updates["processed_at"] = now
+	case attempts >= MaxAttempts:
+		updates["status"] = models.OutboxStatusFailed
+		updates["last_error"] = err.Error()
+		log.Printf("Giving up on event %s (%s): %v", event.ID, event.Name, err)
+	default:
+		updates["next_attempt_at"] = now.Add(backoff(attempts))
+		updates["last_error"] = err.Error()
+	}
+
+	if err := db.Model(&models.OutboxEvent{}).Where("id = ?", stored.ID).Updates(updates).Error; err != nil {
+		log.Printf("Error recording event %s: %v", event.ID, err)
+	}
+}
+
+// relayDue processes every event that is due, a batch at a time
+func relayDue(db *gorm.DB) error {
+	for {
+		due, err := claim(db)
+		if err != nil || len(due) == 0 {
+			return err
+		}
+		for _, event := range due {
+			process(db, event)
+		}
+	}
+}
+
+// Start relays stored events to the subscribers in the background, checking
+// for new and retried events every poll interval. Register every subscriber
+// before calling Start, or it misses the events relayed in the meantime.
+func Start(db *gorm.DB, poll time.Duration) {
+	go func() {
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			if err := relayDue(db); err != nil {
+				log.Printf("Error relaying events: %v", err)
+			}
+			if time.Since(lastCleanup) > time.Hour {
+				if err := db.Where("status = ? AND processed_at < ?", models.OutboxStatusProcessed, time.Now().Add(-retention)).
+					Delete(&models.OutboxEvent{}).Error; err != nil {
+					log.Printf("Error removing processed events: %v", err)
+				}
+				lastCleanup = time.Now()
+			}
+			select {
+			case <-ticker.C:
+			case <-wakeCh:
+			}
+		}
+	}()
+}
+
+// Retry schedules a failed event to be handed to the subscribers that have
+// not handled it yet, with a fresh set of attempts
+func Retry(db *gorm.DB, id uuid.UUID) error {
+	if err := db.Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]any{
+		"status":          models.OutboxStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	wake()
+	return nil
+}
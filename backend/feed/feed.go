@@ -0,0 +1,266 @@
+// Package feed renders syndication feeds in the RSS 2.0 and Atom 1.0
+// formats from one description of their items.
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// Content types of the rendered feeds
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+)
+
+// Person is an author of an item. URI, when set, links to their profile.
+type Person struct {
+	Name string
+	URI  string
+}
+
+// Enclosure is a media file attached to an item, such as a cover image.
+// Length is the size in bytes, or 0 when it is not known.
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// Item is one entry of a feed. ID must stay the same for the lifetime of the
+// item so readers do not show it twice. Content is HTML.
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Content     string
+	Authors     []Person
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+	Enclosure   *Enclosure
+}
+
+// Feed is a list of items, newest first. Self is the URL the feed is served
+// from and Link the web page it mirrors.
+type Feed struct {
+	ID          string
+	Title       string
+	Link        string
+	Self        string
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          *atomLink `xml:"atom:link,omitempty"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description"`
+	Content     *cdata        `xml:"content:encoded,omitempty"`
+	Creators    []string      `xml:"dc:creator"`
+	Categories  []string      `xml:"category"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// RSS renders the feed as an RSS 2.0 document. Content goes into
+// content:encoded and authors into dc:creator, since the RSS author element
+// must be an email address.
+func (f *Feed) RSS() ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		Generator:   "Avions Club",
+	}
+	if f.Self != "" {
+		channel.Self = &atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID},
+			Description: item.Description,
+			Categories:  item.Categories,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		}
+		if item.Content != "" {
+			entry.Content = &cdata{Value: item.Content}
+		}
+		for _, author := range item.Authors {
+			entry.Creators = append(entry.Creators, author.Name)
+		}
+		if item.Enclosure != nil {
+			entry.Enclosure = &rssEnclosure{
+				URL:    item.Enclosure.URL,
+				Length: item.Enclosure.Length,
+				Type:   item.Enclosure.Type,
+			}
+		}
+		channel.Items = append(channel.Items, entry)
+	}
+
+	return marshal(rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel,
+	})
+}
+
+type atomDocument struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Authors    []atomPerson   `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    *atomContent   `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Atom renders the feed as an Atom 1.0 document. Atom requires an author for
+// every entry, so entries without one name the feed's title instead.
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomDocument{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+	}
+	if f.Link != "" {
+		doc.Links = append(doc.Links, atomLink{Href: f.Link, Rel: "alternate", Type: "text/html"})
+	}
+	if f.Self != "" {
+		doc.Links = append(doc.Links, atomLink{Href: f.Self, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	for _, item := range f.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Updated:   updated.UTC().Format(time.RFC3339),
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Summary:   item.Description,
+		}
+		for _, author := range item.Authors {
+			entry.Authors = append(entry.Authors, atomPerson{Name: author.Name, URI: author.URI})
+		}
+		if len(entry.Authors) == 0 {
+			entry.Authors = []atomPerson{{Name: f.Title}}
+		}
+		if item.Link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"})
+		}
+		if item.Enclosure != nil {
+			link := atomLink{Href: item.Enclosure.URL, Rel: "enclosure", Type: item.Enclosure.Type}
+			if item.Enclosure.Length > 0 {
+				link.Length = strconv.FormatInt(item.Enclosure.Length, 10)
+			}
+			entry.Links = append(entry.Links, link)
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if item.Content != "" {
+			entry.Content = &atomContent{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshal(doc)
+}
+
+// marshal encodes a document with the XML declaration
+func marshal(doc any) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
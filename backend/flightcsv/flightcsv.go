@@ -0,0 +1,314 @@
+// Package flightcsv reads flight logs exported as CSV by ground-station
+// software. Tools name their columns differently, so headers are matched
+// loosely against known aliases and unknown columns are ignored.
+package flightcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoRows is returned when the file holds no flights
+	ErrNoRows = errors.New("file contains no flights")
+	// ErrTooManyRows is returned when the file holds more flights than allowed
+	ErrTooManyRows = errors.New("file contains too many flights")
+)
+
+// Row is one flight read from an export. Text fields are empty when the
+// column is missing or the cell is blank.
+type Row struct {
+	Line       int
+	FlownAt    time.Time
+	Duration   time.Duration
+	Aircraft   string
+	Pilot      string
+	Location   string
+	Conditions string
+	Outcome    string
+	Notes      string
+}
+
+// Error describes a cell that could not be read. Column is the name of the
+// flight log field it was meant for.
+type Error struct {
+	Line    int
+	Column  string
+	Message string
+}
+
+// Column keys that headers are mapped to
+const (
+	colDateTime        = "flownAt"
+	colDate            = "date"
+	colTime            = "time"
+	colDuration        = "duration"
+	colDurationMinutes = "durationMinutes"
+	colAircraft        = "aircraft"
+	colPilot           = "pilot"
+	colLocation        = "location"
+	colConditions      = "conditions"
+	colOutcome         = "outcome"
+	colNotes           = "notes"
+)
+
+// headerAliases maps normalized header names to column keys
+var headerAliases = map[string]string{
+	"datetime": colDateTime, "starttime": colDateTime, "start": colDateTime,
+	"takeoff": colDateTime, "takeofftime": colDateTime, "timestamp": colDateTime,
+	"flownat": colDateTime,
+
+	"date": colDate, "flightdate": colDate,
+	"time": colTime,
+
+	"duration": colDuration, "durations": colDuration, "durationsec": colDuration,
+	"durationseconds": colDuration, "flighttime": colDuration, "flighttimes": colDuration,
+	"flighttimesec": colDuration, "airtime": colDuration, "airtimes": colDuration,
+	"totaltime": colDuration,
+
+	"durationmin": colDurationMinutes, "durationminutes": colDurationMinutes,
+	"flighttimemin": colDurationMinutes, "flighttimeminutes": colDurationMinutes,
+	"airtimemin": colDurationMinutes, "minutes": colDurationMinutes,
+
+	"aircraft": colAircraft, "vehicle": colAircraft, "vehiclename": colAircraft,
+	"airframe": colAircraft, "drone": colAircraft, "uav": colAircraft, "model": colAircraft,
+
+	"pilot": colPilot, "pilotname": colPilot, "pic": colPilot, "operator": colPilot,
+
+	"location": colLocation, "site": colLocation, "field": colLocation, "place": colLocation,
+
+	"conditions": colConditions, "weather": colConditions, "wind": colConditions,
+
+	"outcome": colOutcome, "result": colOutcome, "status": colOutcome,
+
+	"notes": colNotes, "note": colNotes, "comments": colNotes, "comment": colNotes,
+	"remarks": colNotes,
+}
+
+// outcomeAliases maps common result wording to flight log outcomes
+var outcomeAliases = map[string]string{
+	"ok": "success", "successful": "success", "completed": "success", "complete": "success",
+	"landed": "success", "abort": "aborted", "cancelled": "aborted", "rtl": "aborted",
+	"crashed": "crash", "lost": "crash", "failure": "crash", "failed": "crash",
+}
+
+// dateTimeLayouts are the accepted timestamp formats. Separate date and time
+// columns are joined with a space before parsing.
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"20060102 15:04:05",
+	"20060102 15:04",
+	"20060102",
+}
+
+// minUnixSeconds is the smallest number read as a Unix timestamp, in
+// September 2001. Smaller numbers, such as the compact date 20250314, are
+// read as dates instead.
+const minUnixSeconds = 1_000_000_000
+
+// normalizeHeader lowercases a header and drops everything but letters and
+// digits, so "Flight Time (s)" becomes "flighttimes"
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Parse reads the flights of a CSV export. Timestamps without an offset are
+// read in loc. Cells that cannot be read are reported as Errors; the error
+// return is only set when the file as a whole cannot be used.
+func Parse(r io.Reader, loc *time.Location, maxRows int) ([]Row, []Error, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if key, ok := headerAliases[normalizeHeader(name)]; ok {
+			if _, seen := columns[key]; !seen {
+				columns[key] = i
+			}
+		}
+	}
+	if _, ok := columns[colDateTime]; !ok {
+		if _, ok := columns[colDate]; !ok {
+			return nil, nil, errors.New("missing a date or start time column")
+		}
+	}
+	_, hasSeconds := columns[colDuration]
+	_, hasMinutes := columns[colDurationMinutes]
+	if !hasSeconds && !hasMinutes {
+		return nil, nil, errors.New("missing a duration or flight time column")
+	}
+
+	var rows []Row
+	var rowErrs []Error
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if isBlank(record) {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, nil, ErrTooManyRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		cell := func(key string) string {
+			if i, ok := columns[key]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		fail := func(column, message string) {
+			rowErrs = append(rowErrs, Error{Line: line, Column: column, Message: message})
+		}
+
+		row := Row{
+			Line:       line,
+			Aircraft:   cell(colAircraft),
+			Pilot:      cell(colPilot),
+			Location:   cell(colLocation),
+			Conditions: cell(colConditions),
+			Outcome:    normalizeOutcome(cell(colOutcome)),
+			Notes:      cell(colNotes),
+		}
+
+		flownAt, err := parseFlownAt(cell(colDateTime), cell(colDate), cell(colTime), loc)
+		if err != nil {
+			fail("flownAt", err.Error())
+		}
+		row.FlownAt = flownAt
+
+		if hasSeconds && cell(colDuration) != "" {
+			row.Duration, err = parseDuration(cell(colDuration), time.Second)
+		} else {
+			row.Duration, err = parseDuration(cell(colDurationMinutes), time.Minute)
+		}
+		if err != nil {
+			fail("durationSeconds", err.Error())
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, ErrNoRows
+	}
+	return rows, rowErrs, nil
+}
+
+// isBlank reports whether every cell of a record is empty
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFlownAt reads the start of a flight from a combined date-time cell or
+// from separate date and time cells. Unix timestamps from 2001 on are
+// accepted too.
+func parseFlownAt(dateTime, date, clock string, loc *time.Location) (time.Time, error) {
+	if dateTime == "" {
+		dateTime = strings.TrimSpace(date + " " + clock)
+	}
+	if dateTime == "" {
+		return time.Time{}, errors.New("is required")
+	}
+
+	if seconds, err := strconv.ParseInt(dateTime, 10, 64); err == nil && seconds >= minUnixSeconds {
+		return time.Unix(seconds, 0), nil
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, dateTime, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("is not a recognised date: %q", dateTime)
+}
+
+// parseDuration reads a flight duration written as a number of units,
+// as hh:mm:ss or mm:ss, or as a Go duration such as "12m30s"
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	if value == "" {
+		return 0, errors.New("is required")
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		if number <= 0 || math.IsInf(number, 0) || math.IsNaN(number) {
+			return 0, errors.New("must be positive")
+		}
+		return time.Duration(number * float64(unit)).Round(time.Second), nil
+	}
+
+	if parts := strings.Split(value, ":"); len(parts) == 2 || len(parts) == 3 {
+		var total time.Duration
+		for _, part := range parts {
+			n, err := strconv.ParseFloat(part, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("is not a recognised duration: %q", value)
+			}
+			total = total*60 + time.Duration(n*float64(time.Second))
+		}
+		if total <= 0 {
+			return 0, errors.New("must be positive")
+		}
+		return total.Round(time.Second), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("is not a recognised duration: %q", value)
+	}
+	if d <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return d.Round(time.Second), nil
+}
+
+// normalizeOutcome lowercases an outcome and maps common wording onto the
+// flight log outcomes. Unknown values are returned as is for validation.
+func normalizeOutcome(value string) string {
+	value = strings.ToLower(value)
+	if outcome, ok := outcomeAliases[value]; ok {
+		return outcome
+	}
+	return value
+}
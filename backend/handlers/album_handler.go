@@ -0,0 +1,665 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+	"avions-club/backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Limits on bulk photo uploads
+const (
+	maxAlbumUploadFiles = 50
+	maxPhotoSize        = 5 << 20
+)
+
+// errPhotoOrder is returned when a reorder does not list every photo of an album exactly once
+var errPhotoOrder = errors.New("photo order does not match the album")
+
+// AlbumRequest is the body accepted when creating or replacing an album
+type AlbumRequest struct {
+	Title       string     `json:"title" binding:"required,max=255"`
+	Description string     `json:"description" binding:"max=10000"`
+	EventID     *uuid.UUID `json:"eventId"`
+	ProjectID   *uuid.UUID `json:"projectId"`
+	CoverURL    string     `json:"coverUrl" binding:"omitempty,max=2048,storageurl=images"`
+}
+
+// newAlbumRequest returns the request that would recreate an album as it is
+func newAlbumRequest(album *models.Album) AlbumRequest {
+	return AlbumRequest{
+		Title:       album.Title,
+		Description: album.Description,
+		EventID:     album.EventID,
+		ProjectID:   album.ProjectID,
+		CoverURL:    album.CoverURL,
+	}
+}
+
+// apply copies the request onto an album
+func (r *AlbumRequest) apply(album *models.Album) {
+	album.Title = r.Title
+	album.Description = r.Description
+	album.EventID = r.EventID
+	album.ProjectID = r.ProjectID
+	album.CoverURL = r.CoverURL
+}
+
+// checkAlbumRequest verifies the event and project of a decoded album
+// request. On failure it writes the error envelope and returns false.
+func checkAlbumRequest(c *gin.Context, req *AlbumRequest) bool {
+	fields, err := missingIDField(&models.Event{}, "eventId", req.EventID, "must reference an existing event")
+	if err != nil {
+		apierror.Internal(c, "Error checking album event")
+		return false
+	}
+	projectFields, err := missingIDField(&models.Project{}, "projectId", req.ProjectID, "must reference an existing project")
+	if err != nil {
+		apierror.Internal(c, "Error checking album project")
+		return false
+	}
+	if fields = append(fields, projectFields...); len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// PhotoRequest describes one already uploaded image to add to an album
+type PhotoRequest struct {
+	URL            string     `json:"url" binding:"required,max=2048,storageurl=images"`
+	Caption        string     `json:"caption" binding:"max=2000"`
+	CreditMemberID *uuid.UUID `json:"creditMemberId"`
+}
+
+// newPhotoRequest returns the request that would recreate a photo as it is
+func newPhotoRequest(photo *models.Photo) PhotoRequest {
+	return PhotoRequest{
+		URL:            photo.URL,
+		Caption:        photo.Caption,
+		CreditMemberID: photo.CreditMemberID,
+	}
+}
+
+// apply copies the request onto a photo
+func (r *PhotoRequest) apply(photo *models.Photo) {
+	photo.URL = r.URL
+	photo.Caption = r.Caption
+	photo.CreditMemberID = r.CreditMemberID
+}
+
+// AddPhotosRequest is the body accepted when adding uploaded images to an album
+type AddPhotosRequest struct {
+	Photos []PhotoRequest `json:"photos" binding:"required,min=1,max=100,dive"`
+}
+
+// ReorderPhotosRequest lists every photo of an album in its new order
+type ReorderPhotosRequest struct {
+	PhotoIDs []uuid.UUID `json:"photoIds" binding:"required,dive,required"`
+}
+
+// checkPhotoCredits verifies the members credited with photos. field formats
+// the field name of the i-th photo. On failure it writes the error envelope
+// and returns false.
+func checkPhotoCredits(c *gin.Context, credits []*uuid.UUID, field func(i int) string) bool {
+	var ids []uuid.UUID
+	for _, id := range credits {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	exists, err := existingIDs(&models.Member{}, ids)
+	if err != nil {
+		apierror.Internal(c, "Error checking photo credits")
+		return false
+	}
+
+	var fields []apierror.FieldError
+	for i, id := range credits {
+		if id != nil && !exists[*id] {
+			fields = append(fields, apierror.FieldError{
+				Field:   field(i),
+				Code:    "not_found",
+				Message: "must reference an existing member",
+			})
+		}
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// withAlbumLinks preloads the event and project an album covers
+func withAlbumLinks(db *gorm.DB) *gorm.DB {
+	return db.Preload("Event").Preload("Project")
+}
+
+// withAlbumPhotos preloads the photos of an album in order, with the members
+// they are credited to
+func withAlbumPhotos(db *gorm.DB) *gorm.DB {
+	return db.Scopes(withAlbumLinks).Preload("Photos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, created_at")
+	}).Preload("Photos.Credit")
+}
+
+// countPhotos fills in the photo count of each album
+func countPhotos(albums []models.Album) error {
+	if len(albums) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(albums))
+	for _, album := range albums {
+		ids = append(ids, album.ID)
+	}
+
+	var counts []struct {
+		AlbumID uuid.UUID
+		Count   int64
+	}
+	if err := database.DB.Model(&models.Photo{}).
+		Select("album_id, COUNT(*) AS count").
+		Where("album_id IN ?", ids).
+		Group("album_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	index := make(map[uuid.UUID]*models.Album, len(albums))
+	for i := range albums {
+		index[albums[i].ID] = &albums[i]
+	}
+	for _, count := range counts {
+		if album, ok := index[count.AlbumID]; ok {
+			album.PhotoCount = count.Count
+		}
+	}
+	return nil
+}
+
+// findAlbum loads an album with its photos by the :id path parameter. On
+// failure it writes the error envelope and returns false.
+func findAlbum(c *gin.Context, album *models.Album) bool {
+	id, ok := parseID(c, "album")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withAlbumPhotos).First(album, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Album not found with ID: %s", id))
+		return false
+	}
+	album.PhotoCount = int64(len(album.Photos))
+	return true
+}
+
+// findAlbumPhoto loads a photo of an album by the :photoId path parameter.
+// On failure it writes the error envelope and returns false.
+func findAlbumPhoto(c *gin.Context, album *models.Album, photo *models.Photo) bool {
+	photoID, err := uuid.Parse(c.Param("photoId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid photo ID format: %s", c.Param("photoId")))
+		return false
+	}
+
+	if err := database.DB.First(photo, "id = ? AND album_id = ?", photoID, album.ID).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Photo not found with ID: %s", photoID))
+		return false
+	}
+	return true
+}
+
+// respondWithAlbum reloads an album after a write so the response and its
+// ETag match what a subsequent GET returns
+func respondWithAlbum(c *gin.Context, status int, id uuid.UUID) {
+	var album models.Album
+	if err := database.DB.Scopes(withAlbumPhotos).First(&album, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching album")
+		return
+	}
+	album.PhotoCount = int64(len(album.Photos))
+
+	respondWithETag(c, status, album)
+}
+
+// GetAlbums returns albums without their photos, newest first. eventId and
+// projectId filter.
+func GetAlbums(c *gin.Context) {
+	db := database.DB.Scopes(withAlbumLinks).Order("created_at DESC")
+	for _, filter := range []struct{ param, column string }{
+		{"eventId", "event_id"},
+		{"projectId", "project_id"},
+	} {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid %s format: %s", filter.param, value))
+			return
+		}
+		db = db.Where(filter.column+" = ?", id)
+	}
+
+	var albums []models.Album
+	if err := db.Find(&albums).Error; err != nil {
+		apierror.Internal(c, "Error fetching albums")
+		return
+	}
+	if err := countPhotos(albums); err != nil {
+		apierror.Internal(c, "Error counting photos")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, albums)
+}
+
+// GetAlbum returns a specific album with its photos in order
+func GetAlbum(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, album)
+}
+
+// CreateAlbum creates a new, empty album
+func CreateAlbum(c *gin.Context) {
+	var req AlbumRequest
+	if !bindJSON(c, &req) || !checkAlbumRequest(c, &req) {
+		return
+	}
+
+	album := models.Album{ID: uuid.New()}
+	req.apply(&album)
+	if err := database.DB.Omit(clause.Associations).Create(&album).Error; err != nil {
+		apierror.Internal(c, "Error creating album")
+		return
+	}
+
+	respondWithAlbum(c, http.StatusCreated, album.ID)
+}
+
+// saveAlbum applies a validated request to an album and writes the result
+func saveAlbum(c *gin.Context, album *models.Album, req *AlbumRequest) {
+	req.apply(album)
+	if err := saveVersioned(database.DB, album, &album.Version); err != nil {
+		respondWriteError(c, err, "Error updating album")
+		return
+	}
+
+	respondWithAlbum(c, http.StatusOK, album.ID)
+}
+
+// UpdateAlbum replaces the details of an existing album
+func UpdateAlbum(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) || !checkIfMatch(c, album) {
+		return
+	}
+
+	var req AlbumRequest
+	if !bindJSON(c, &req) || !checkAlbumRequest(c, &req) {
+		return
+	}
+
+	saveAlbum(c, &album, &req)
+}
+
+// PatchAlbum partially updates an album using JSON Merge Patch
+func PatchAlbum(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) || !checkIfMatch(c, album) {
+		return
+	}
+
+	req := newAlbumRequest(&album)
+	if !bindMergePatch(c, &req) || !checkAlbumRequest(c, &req) {
+		return
+	}
+
+	saveAlbum(c, &album, &req)
+}
+
+// DeleteAlbum deletes an album. The uploaded images are kept in storage.
+func DeleteAlbum(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) || !checkIfMatch(c, album) {
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &album, album.Version); err != nil {
+		respondWriteError(c, err, "Error deleting album")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Album deleted successfully"})
+}
+
+// appendPhotos adds photos to the end of an album. The album row is locked so
+// concurrent additions do not share positions.
+func appendPhotos(albumID uuid.UUID, photos []models.Photo) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&models.Album{}, "id = ?", albumID).Error; err != nil {
+			return err
+		}
+
+		var last int
+		if err := tx.Model(&models.Photo{}).
+			Select("COALESCE(MAX(position), 0)").
+			Where("album_id = ?", albumID).
+			Scan(&last).Error; err != nil {
+			return err
+		}
+
+		for i := range photos {
+			photos[i].AlbumID = albumID
+			photos[i].Position = last + i + 1
+		}
+		return tx.Omit(clause.Associations).Create(&photos).Error
+	})
+}
+
+// AddPhotos adds already uploaded images to the end of an album
+func AddPhotos(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) {
+		return
+	}
+
+	var req AddPhotosRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	credits := make([]*uuid.UUID, len(req.Photos))
+	for i := range req.Photos {
+		credits[i] = req.Photos[i].CreditMemberID
+	}
+	if !checkPhotoCredits(c, credits, func(i int) string {
+		return fmt.Sprintf("photos[%d].creditMemberId", i)
+	}) {
+		return
+	}
+
+	photos := make([]models.Photo, len(req.Photos))
+	for i := range req.Photos {
+		req.Photos[i].apply(&photos[i])
+	}
+	if err := appendPhotos(album.ID, photos); err != nil {
+		apierror.Internal(c, "Error adding photos")
+		return
+	}
+
+	respondWithAlbum(c, http.StatusCreated, album.ID)
+}
+
+// checkPhotoUpload verifies that an uploaded file is an image no larger than
+// maxPhotoSize by its extension and content. It returns the message of the
+// field error, or an empty string when the file is acceptable.
+func checkPhotoUpload(file *multipart.FileHeader) string {
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+	default:
+		return "must be a JPEG, PNG, GIF or WebP image"
+	}
+	if file.Size > maxPhotoSize {
+		return "must be at most 5MB"
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "could not be read"
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "could not be read"
+	}
+	if !allowedImageTypes[http.DetectContentType(head[:n])] {
+		return "must be a JPEG, PNG, GIF or WebP image"
+	}
+	return ""
+}
+
+// removeUploads deletes images uploaded for a request that then failed
+func removeUploads(filenames []string) {
+	for _, filename := range filenames {
+		if err := storage.DeleteFile("images", filename); err != nil {
+			log.Printf("Error removing orphaned upload %s: %v", filename, err)
+		}
+	}
+}
+
+// UploadPhotos uploads images to storage and adds them to the end of an
+// album. The multipart form carries the images as repeated "files" fields,
+// optional "captions" in the same order and an optional "creditMemberId"
+// applied to every photo.
+func UploadPhotos(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) {
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		apierror.BadRequest(c, "Invalid multipart form")
+		return
+	}
+	files := form.File["files"]
+	captions := form.Value["captions"]
+
+	var fields []apierror.FieldError
+	switch {
+	case len(files) == 0:
+		fields = append(fields, apierror.FieldError{Field: "files", Code: "required", Message: "is required"})
+	case len(files) > maxAlbumUploadFiles:
+		fields = append(fields, apierror.FieldError{
+			Field:   "files",
+			Code:    "too_long",
+			Message: fmt.Sprintf("must have at most %d files", maxAlbumUploadFiles),
+		})
+	}
+	if len(captions) > len(files) {
+		fields = append(fields, apierror.FieldError{
+			Field:   "captions",
+			Code:    "too_long",
+			Message: "must not have more entries than files",
+		})
+	}
+	for i, caption := range captions {
+		if len(caption) > 2000 {
+			fields = append(fields, apierror.FieldError{
+				Field:   fmt.Sprintf("captions[%d]", i),
+				Code:    "too_long",
+				Message: "must be at most 2000 characters",
+			})
+		}
+	}
+	if len(files) <= maxAlbumUploadFiles {
+		for i, file := range files {
+			if message := checkPhotoUpload(file); message != "" {
+				fields = append(fields, apierror.FieldError{
+					Field:   fmt.Sprintf("files[%d]", i),
+					Code:    "invalid_type",
+					Message: message,
+				})
+			}
+		}
+	}
+
+	var credit *uuid.UUID
+	if raw := c.PostForm("creditMemberId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			fields = append(fields, apierror.FieldError{
+				Field:   "creditMemberId",
+				Code:    "invalid",
+				Message: "must be a member ID",
+			})
+		} else {
+			credit = &id
+		}
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return
+	}
+	if !checkPhotoCredits(c, []*uuid.UUID{credit}, func(int) string { return "creditMemberId" }) {
+		return
+	}
+
+	photos := make([]models.Photo, 0, len(files))
+	uploaded := make([]string, 0, len(files))
+	for i, file := range files {
+		filename := uuid.New().String() + strings.ToLower(filepath.Ext(file.Filename))
+		url, err := storage.UploadFile(file, filename)
+		if err != nil {
+			log.Printf("Error uploading photo %s: %v", file.Filename, err)
+			removeUploads(uploaded)
+			apierror.Internal(c, "Failed to upload photos")
+			return
+		}
+		uploaded = append(uploaded, filename)
+
+		photo := models.Photo{URL: url, CreditMemberID: credit}
+		if i < len(captions) {
+			photo.Caption = strings.TrimSpace(captions[i])
+		}
+		photos = append(photos, photo)
+	}
+
+	if err := appendPhotos(album.ID, photos); err != nil {
+		removeUploads(uploaded)
+		apierror.Internal(c, "Error adding photos")
+		return
+	}
+	for _, photo := range photos {
+		publishUpload(photo.URL)
+	}
+
+	respondWithAlbum(c, http.StatusCreated, album.ID)
+}
+
+// PatchPhoto partially updates the image, caption or credit of a photo using
+// JSON Merge Patch
+func PatchPhoto(c *gin.Context) {
+	var album models.Album
+	var photo models.Photo
+	if !findAlbum(c, &album) || !findAlbumPhoto(c, &album, &photo) {
+		return
+	}
+
+	req := newPhotoRequest(&photo)
+	if !bindMergePatch(c, &req) {
+		return
+	}
+	if !checkPhotoCredits(c, []*uuid.UUID{req.CreditMemberID}, func(int) string { return "creditMemberId" }) {
+		return
+	}
+
+	req.apply(&photo)
+	if err := database.DB.Omit(clause.Associations).Save(&photo).Error; err != nil {
+		apierror.Internal(c, "Error updating photo")
+		return
+	}
+
+	respondWithAlbum(c, http.StatusOK, album.ID)
+}
+
+// DeletePhoto removes a photo from an album. The uploaded image is kept in
+// storage, since it may also be used elsewhere.
+func DeletePhoto(c *gin.Context) {
+	var album models.Album
+	var photo models.Photo
+	if !findAlbum(c, &album) || !findAlbumPhoto(c, &album, &photo) {
+		return
+	}
+
+	if err := database.DB.Delete(&photo).Error; err != nil {
+		apierror.Internal(c, "Error deleting photo")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Photo deleted successfully"})
+}
+
+// ReorderPhotos sets the order of the photos in an album. photoIds must list
+// every photo of the album exactly once.
+func ReorderPhotos(c *gin.Context) {
+	var album models.Album
+	if !findAlbum(c, &album) {
+		return
+	}
+
+	var req ReorderPhotosRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&models.Album{}, "id = ?", album.ID).Error; err != nil {
+			return err
+		}
+
+		var current []uuid.UUID
+		if err := tx.Model(&models.Photo{}).Where("album_id = ?", album.ID).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+		remaining := make(map[uuid.UUID]bool, len(current))
+		for _, id := range current {
+			remaining[id] = true
+		}
+		for _, id := range req.PhotoIDs {
+			if !remaining[id] {
+				return errPhotoOrder
+			}
+			delete(remaining, id)
+		}
+		if len(remaining) > 0 {
+			return errPhotoOrder
+		}
+
+		for i, id := range req.PhotoIDs {
+			if err := tx.Model(&models.Photo{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errPhotoOrder) {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "photoIds",
+			Code:    "invalid",
+			Message: "must list every photo of the album exactly once",
+		}})
+		return
+	}
+	if err != nil {
+		apierror.Internal(c, "Error reordering photos")
+		return
+	}
+
+	respondWithAlbum(c, http.StatusOK, album.ID)
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errApplicationClosed is returned when an application was already decided
+var errApplicationClosed = errors.New("application has already been decided")
+
+// errAccountExists is returned when approving with an account for an email
+// that already has one
+var errAccountExists = errors.New("an account already exists for this email")
+
+// ApplicationRequest is the body of the public membership form. Website is
+// a honeypot, see honeypotTripped.
+type ApplicationRequest struct {
+	Name        string   `json:"name" binding:"required,max=255"`
+	Email       string   `json:"email" binding:"required,email,max=255"`
+	Department  string   `json:"department" binding:"max=255"`
+	YearOfStudy *int     `json:"yearOfStudy" binding:"omitempty,min=1,max=10"`
+	Skills      []string `json:"skills" binding:"omitempty,max=30,dive,required,max=50"`
+	Motivation  string   `json:"motivation" binding:"required,min=20,max=5000"`
+	Website     string   `json:"website"`
+}
+
+// ApplicationStatusRequest moves an application through the review queue
+type ApplicationStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending reviewing rejected"`
+	Notes  string `json:"notes" binding:"max=5000"`
+}
+
+// ApproveApplicationRequest is the body accepted when approving an application
+type ApproveApplicationRequest struct {
+	Position      string `json:"position" binding:"max=255"`
+	Notes         string `json:"notes" binding:"max=5000"`
+	CreateAccount bool   `json:"createAccount"`
+}
+
+// ApprovalResponse is returned when an application is approved. InviteToken
+// is only set when an account was created and is never shown again.
+type ApprovalResponse struct {
+	Application models.Application `json:"application"`
+	User        *models.User       `json:"user,omitempty"`
+	InviteToken string             `json:"inviteToken,omitempty"`
+}
+
+// SubmitApplication records a membership application from the public form
+func SubmitApplication(c *gin.Context) {
+	var req ApplicationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	accepted := gin.H{"message": "Application received; an officer will be in touch"}
+	if honeypotTripped(c, req.Website, http.StatusAccepted, accepted) {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var open int64
+	if err := database.DB.Model(&models.Application{}).
+		Where("email = ? AND status IN ?", email,
+			[]string{models.ApplicationStatusPending, models.ApplicationStatusReviewing}).
+		Count(&open).Error; err != nil {
+		apierror.Internal(c, "Error checking existing applications")
+		return
+	}
+	if open > 0 {
+		apierror.Conflict(c, "An application with this email is already being reviewed")
+		return
+	}
+
+	application := models.Application{
+		Name:        strings.TrimSpace(req.Name),
+		Email:       email,
+		Department:  req.Department,
+		YearOfStudy: req.YearOfStudy,
+		Skills:      normalizeTags(req.Skills),
+		Motivation:  req.Motivation,
+		Status:      models.ApplicationStatusPending,
+		IPAddress:   c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+	}
+	if err := database.DB.Create(&application).Error; err != nil {
+		apierror.Internal(c, "Error submitting application")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// GetApplications returns the review queue, oldest first. The status query
+// parameter defaults to pending and reviewing applications; "all" lists every
+// application.
+func GetApplications(c *gin.Context) {
+	db := database.DB.Order("created_at")
+	switch status := c.Query("status"); status {
+	case "":
+		db = db.Where("status IN ?",
+			[]string{models.ApplicationStatusPending, models.ApplicationStatusReviewing})
+	case "all":
+	case models.ApplicationStatusPending, models.ApplicationStatusReviewing,
+		models.ApplicationStatusApproved, models.ApplicationStatusRejected:
+		db = db.Where("status = ?", status)
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: all, pending, reviewing, approved, rejected",
+		}})
+		return
+	}
+
+	var applications []models.Application
+	if err := db.Find(&applications).Error; err != nil {
+		apierror.Internal(c, "Error fetching applications")
+		return
+	}
+
+	c.JSON(http.StatusOK, applications)
+}
+
+// findApplication loads an application by the :id path parameter. On failure
+// it writes the error envelope and returns false.
+func findApplication(c *gin.Context, db *gorm.DB, application *models.Application) bool {
+	id, ok := parseID(c, "application")
+	if !ok {
+		return false
+	}
+
+	if err := db.Preload("Member").First(application, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Application not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// GetApplication returns a specific application
+func GetApplication(c *gin.Context) {
+	var application models.Application
+	if !findApplication(c, database.DB, &application) {
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+// UpdateApplicationStatus moves an undecided application to another review
+// status, e.g. from pending to reviewing, or rejects it
+func UpdateApplicationStatus(c *gin.Context) {
+	var application models.Application
+	if !findApplication(c, database.DB, &application) {
+		return
+	}
+
+	var req ApplicationStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	updates := map[string]any{"status": req.Status, "review_notes": req.Notes}
+	if req.Status == models.ApplicationStatusRejected {
+		updates["reviewed_at"] = time.Now()
+	}
+	result := database.DB.Model(&application).
+		Where("status IN ?", []string{models.ApplicationStatusPending, models.ApplicationStatusReviewing}).
+		Updates(updates)
+	if result.Error != nil {
+		apierror.Internal(c, "Error updating application")
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.Conflict(c, errApplicationClosed.Error())
+		return
+	}
+
+	if err := database.DB.Preload("Member").First(&application, "id = ?", application.ID).Error; err != nil {
+		apierror.Internal(c, "Error fetching updated application")
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+// ApproveApplication turns an application into a member, and optionally an
+// invited user account, in a single transaction
+func ApproveApplication(c *gin.Context) {
+	var application models.Application
+	if !findApplication(c, database.DB, &application) {
+		return
+	}
+
+	var req ApproveApplicationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Position == "" {
+		req.Position = "Member"
+	}
+
+	var response ApprovalResponse
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the application so two officers cannot approve it twice
+		var current models.Application
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "id = ?", application.ID).Error; err != nil {
+			return err
+		}
+		if current.Status == models.ApplicationStatusApproved ||
+			current.Status == models.ApplicationStatusRejected {
+			return errApplicationClosed
+		}
+		if req.CreateAccount {
+			var accounts int64
+			if err := tx.Model(&models.User{}).
+				Where("LOWER(email) = LOWER(?)", current.Email).
+				Count(&accounts).Error; err != nil {
+				return err
+			}
+			if accounts > 0 {
+				return errAccountExists
+			}
+		}
+
+		member := models.Member{
+			Name:        current.Name,
+			Position:    req.Position,
+			Status:      models.MemberStatusActive,
+			Department:  current.Department,
+			YearOfStudy: current.YearOfStudy,
+			Skills:      current.Skills,
+		}
+		contacts := []ContactRequest{{
+			Kind:       "email",
+			Value:      current.Email,
+			Visibility: models.ContactVisibilityPrivate,
+		}}
+		if err := createMember(tx, &member, contacts); err != nil {
+			return err
+		}
+
+		if req.CreateAccount {
+			token, hash, err := newSecretToken()
+			if err != nil {
+				return err
+			}
+			user := models.User{
+				Email:           current.Email,
+				MemberID:        member.ID,
+				InviteTokenHash: hash,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			response.User = &user
+			response.InviteToken = token
+		}
+
+		now := time.Now()
+		return tx.Model(&current).Updates(map[string]any{
+			"status":       models.ApplicationStatusApproved,
+			"review_notes": req.Notes,
+			"reviewed_at":  now,
+			"member_id":    member.ID,
+		}).Error
+	})
+	if errors.Is(err, errApplicationClosed) || errors.Is(err, errAccountExists) {
+		apierror.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Internal(c, "Error approving application")
+		return
+	}
+
+	if err := database.DB.Preload("Member").First(&response.Application, "id = ?", application.ID).Error; err != nil {
+		apierror.Internal(c, "Error fetching approved application")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditExportBatch is how many entries the export reads at a time
+const auditExportBatch = 500
+
+// auditCSVHeader lists the columns of the audit log export
+var auditCSVHeader = []string{
+	"createdAt", "actor", "sessionId", "action", "method", "route", "path",
+	"entityType", "entityId", "status", "changes", "ipAddress", "userAgent", "durationMs",
+}
+
+// auditFilters reads the filters shared by the audit log list and export:
+// actor, action, method, entityType, entityId and status match exactly, and
+// from and to bound the time. On failure it writes the error envelope and
+// returns false.
+func auditFilters(c *gin.Context) (func(*gorm.DB) *gorm.DB, bool) {
+	conditions := map[string]string{}
+	for _, filter := range []struct{ param, column string }{
+		{"actor", "actor"},
+		{"action", "action"},
+		{"method", "method"},
+		{"entityType", "entity_type"},
+		{"entityId", "entity_id"},
+	} {
+		if value := c.Query(filter.param); value != "" {
+			conditions[filter.column] = value
+		}
+	}
+	if method, ok := conditions["method"]; ok {
+		conditions["method"] = strings.ToUpper(method)
+	}
+
+	var status int
+	if raw := c.Query("status"); raw != "" {
+		var err error
+		if status, err = strconv.Atoi(raw); err != nil {
+			apierror.Validation(c, []apierror.FieldError{{
+				Field:   "status",
+				Code:    "invalid_type",
+				Message: "must be an HTTP status code",
+			}})
+			return nil, false
+		}
+	}
+	from, ok := parseDateQuery(c, "from")
+	if !ok {
+		return nil, false
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return nil, false
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		for column, value := range conditions {
+			db = db.Where(column+" = ?", value)
+		}
+		if status != 0 {
+			db = db.Where("status = ?", status)
+		}
+		if from != nil {
+			db = db.Where("created_at >= ?", *from)
+		}
+		if to != nil {
+			db = db.Where("created_at < ?", *to)
+		}
+		return db
+	}, true
+}
+
+// GetAuditLogs returns audit log entries, newest first, filtered as in
+// auditFilters. limit (default 100) and offset page through them.
+func GetAuditLogs(c *gin.Context) {
+	filters, ok := auditFilters(c)
+	if !ok {
+		return
+	}
+	limit, ok := parseIntQuery(c, "limit", 100, 1, 1000)
+	if !ok {
+		return
+	}
+	offset, ok := parseIntQuery(c, "offset", 0, 0, 1<<30)
+	if !ok {
+		return
+	}
+
+	var entries []models.AuditLog
+	if err := database.DB.Scopes(filters).
+		Order("created_at DESC, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error; err != nil {
+		apierror.Internal(c, "Error fetching audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// csvCell guards a value against formula injection when the export is
+// opened in a spreadsheet
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ExportAuditLogs streams the audit log as CSV, oldest first, filtered as in
+// auditFilters
+func ExportAuditLogs(c *gin.Context) {
+	filters, ok := auditFilters(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-log-%s.csv"`,
+		time.Now().UTC().Format("20060102")))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(auditCSVHeader)
+
+	// Page by (created_at, id) so the export stays consistent while new
+	// entries are appended
+	var last *models.AuditLog
+	for {
+		db := database.DB.Scopes(filters).Order("created_at, id").Limit(auditExportBatch)
+		if last != nil {
+			db = db.Where("(created_at, id) > (?, ?)", last.CreatedAt, last.ID)
+		}
+		var entries []models.AuditLog
+		if err := db.Find(&entries).Error; err != nil {
+			// The header is already sent, so a failure can only cut the file short
+			log.Printf("Error exporting audit log: %v", err)
+			break
+		}
+
+		for _, entry := range entries {
+			changes := ""
+			if len(entry.Changes) > 0 {
+				raw, _ := json.Marshal(entry.Changes)
+				changes = string(raw)
+			}
+			_ = w.Write([]string{
+				entry.CreatedAt.UTC().Format(time.RFC3339),
+				csvCell(entry.Actor),
+				entry.SessionID,
+				entry.Action,
+				entry.Method,
+				entry.Route,
+				csvCell(entry.Path),
+				entry.EntityType,
+				csvCell(entry.EntityID),
+				strconv.Itoa(entry.Status),
+				changes,
+				entry.IPAddress,
+				csvCell(entry.UserAgent),
+				strconv.FormatInt(entry.DurationMs, 10),
+			})
+		}
+		w.Flush()
+		if w.Error() != nil || len(entries) < auditExportBatch {
+			break
+		}
+		last = &entries[len(entries)-1]
+	}
+}
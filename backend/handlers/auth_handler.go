@@ -3,35 +3,46 @@ package handlers
 import (
 	"net/http"
 	"os"
+	"strings"
 
+	"avions-club/backend/apierror"
 	"avions-club/backend/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
+// defaultActor is recorded in the audit log for logins that give no name
+const defaultActor = "admin"
+
+// LoginRequest is the body of the admin login. Admins share the password, so
+// the optional name says who is logging in; it is recorded in the audit log.
 type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
+	Name     string `json:"name" binding:"max=100"`
 }
 
 // Login handles admin authentication
 func Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = defaultActor
+	}
 
 	// Check if password matches
 	adminPassword := os.Getenv("ADMIN_PASSWORD")
 	if req.Password != adminPassword {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		apierror.Unauthorized(c, "Invalid password")
 		return
 	}
 
 	// Generate JWT token
-	token, err := middleware.GenerateToken()
+	token, err := middleware.GenerateToken(req.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		apierror.Internal(c, "Error generating token")
 		return
 	}
 
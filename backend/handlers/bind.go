@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// bindJSON decodes and validates the request body into req. On failure it
+// writes the error envelope and returns false.
+func bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		if fields, ok := validation.FieldErrors(err); ok {
+			apierror.Validation(c, fields)
+		} else {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid request body: %v", err))
+		}
+		return false
+	}
+	return true
+}
+
+// honeypotTripped reports whether the website field of a public form was
+// filled in. The field is hidden from people, so only bots fill it in; they
+// get the same status and body as a real submission so they do not learn to
+// skip it.
+func honeypotTripped(c *gin.Context, website string, status int, body any) bool {
+	if website == "" {
+		return false
+	}
+	c.JSON(status, body)
+	return true
+}
+
+// parseID parses the :id path parameter. On failure it writes the error
+// envelope and returns false.
+func parseID(c *gin.Context, entity string) (uuid.UUID, bool) {
+	id := c.Param("id")
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid %s ID format: %s", entity, id))
+		return uuid.Nil, false
+	}
+	return parsed, true
+}
+
+// existingIDs returns which of ids have a row in the table of model
+func existingIDs(model any, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	exists := make(map[uuid.UUID]bool, len(ids))
+	if len(ids) == 0 {
+		return exists, nil
+	}
+
+	var found []uuid.UUID
+	if err := database.DB.Model(model).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		exists[id] = true
+	}
+	return exists, nil
+}
+
+// missingIDFields returns a not_found field error for every entry of ids
+// that is not in exists, e.g. "projectIds[2]"
+func missingIDFields(field string, ids []uuid.UUID, exists map[uuid.UUID]bool, message string) []apierror.FieldError {
+	var fields []apierror.FieldError
+	for i, id := range ids {
+		if !exists[id] {
+			fields = append(fields, apierror.FieldError{
+				Field:   fmt.Sprintf("%s[%d]", field, i),
+				Code:    "not_found",
+				Message: message,
+			})
+		}
+	}
+	return fields
+}
+
+// missingIDField returns a not_found field error when an optional reference
+// is set but has no row in the table of model
+func missingIDField(model any, field string, id *uuid.UUID, message string) ([]apierror.FieldError, error) {
+	if id == nil {
+		return nil, nil
+	}
+	exists, err := existingIDs(model, []uuid.UUID{*id})
+	if err != nil {
+		return nil, err
+	}
+	if exists[*id] {
+		return nil, nil
+	}
+	return []apierror.FieldError{{Field: field, Code: "not_found", Message: message}}, nil
+}
@@ -1,132 +1,340 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"avions-club/backend/apierror"
 	"avions-club/backend/database"
+	"avions-club/backend/events"
 	"avions-club/backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// GetBlogs returns all blogs with their authors
+// BlogRequest is the body accepted when creating or replacing a blog
+type BlogRequest struct {
+	Title          string      `json:"title" binding:"required,max=255"`
+	Description    string      `json:"description" binding:"required,max=5000"`
+	MarkdownURL    string      `json:"markdownUrl" binding:"omitempty,max=2048,storageurl=markdown"`
+	CoverURL       string      `json:"coverUrl" binding:"omitempty,max=2048,storageurl=images"`
+	Tags           []string    `json:"tags" binding:"omitempty,max=20,dive,required,max=50"`
+	AuthorID       uuid.UUID   `json:"authorId" binding:"required"`
+	CoAuthorIDs    []uuid.UUID `json:"coAuthorIds" binding:"omitempty,max=20,dive,required"`
+	CommentsClosed bool        `json:"commentsClosed"`
+}
+
+// newBlogRequest returns the request that would recreate a blog as it is
+func newBlogRequest(blog *models.Blog) (BlogRequest, error) {
+	req := BlogRequest{
+		Title:          blog.Title,
+		Description:    blog.Description,
+		MarkdownURL:    blog.MarkdownURL,
+		CoverURL:       blog.CoverURL,
+		Tags:           blog.Tags,
+		AuthorID:       blog.AuthorID,
+		CommentsClosed: blog.CommentsClosed,
+	}
+	err := database.DB.Model(&models.BlogAuthor{}).
+		Where("blog_id = ? AND position > 0", blog.ID).
+		Order("position").
+		Pluck("member_id", &req.CoAuthorIDs).Error
+	return req, err
+}
+
+// apply copies the request onto a blog
+func (r *BlogRequest) apply(blog *models.Blog) {
+	blog.Title = r.Title
+	blog.Description = r.Description
+	blog.MarkdownURL = r.MarkdownURL
+	blog.CoverURL = r.CoverURL
+	blog.Tags = normalizeTags(r.Tags)
+	blog.AuthorID = r.AuthorID
+	blog.CommentsClosed = r.CommentsClosed
+}
+
+// checkAuthors verifies that the author and every co-author are existing members
+func (r *BlogRequest) checkAuthors() ([]apierror.FieldError, error) {
+	ids := append([]uuid.UUID{r.AuthorID}, r.CoAuthorIDs...)
+
+	var found []uuid.UUID
+	if err := database.DB.Model(&models.Member{}).
+		Where("id IN ?", ids).
+		Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	exists := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+
+	var fields []apierror.FieldError
+	if !exists[r.AuthorID] {
+		fields = append(fields, apierror.FieldError{
+			Field:   "authorId",
+			Code:    "not_found",
+			Message: "must reference an existing member",
+		})
+	}
+	for i, id := range r.CoAuthorIDs {
+		if !exists[id] {
+			fields = append(fields, apierror.FieldError{
+				Field:   fmt.Sprintf("coAuthorIds[%d]", i),
+				Code:    "not_found",
+				Message: "must reference an existing member",
+			})
+		}
+	}
+	return fields, nil
+}
+
+// checkBlogRequest verifies the authors of a decoded blog request. On failure
+// it writes the error envelope and returns false.
+func checkBlogRequest(c *gin.Context, req *BlogRequest) bool {
+	fields, err := req.checkAuthors()
+	if err != nil {
+		apierror.Internal(c, "Error checking blog authors")
+		return false
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// withAuthors preloads the primary author and the ordered byline of a blog
+func withAuthors(db *gorm.DB) *gorm.DB {
+	return db.Preload("Author").
+		Preload("Authors", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Preload("Authors.Member")
+}
+
+// syncBlogAuthors rewrites the byline of a blog: the primary author first,
+// followed by the co-authors in the order they were given
+func syncBlogAuthors(tx *gorm.DB, blog *models.Blog, coAuthorIDs []uuid.UUID) error {
+	authors := []models.BlogAuthor{{BlogID: blog.ID, MemberID: blog.AuthorID, Position: 0}}
+	seen := map[uuid.UUID]bool{blog.AuthorID: true}
+	for _, memberID := range coAuthorIDs {
+		if seen[memberID] {
+			continue
+		}
+		seen[memberID] = true
+		authors = append(authors, models.BlogAuthor{
+			BlogID:   blog.ID,
+			MemberID: memberID,
+			Position: len(authors),
+		})
+	}
+
+	if err := tx.Where("blog_id = ?", blog.ID).Delete(&models.BlogAuthor{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&authors).Error
+}
+
+// GetBlogs returns all blogs with their authors, optionally only those with
+// a tag
 func GetBlogs(c *gin.Context) {
+	db := database.DB.Scopes(withAuthors)
+	if tag := c.Query("tag"); tag != "" {
+		tags, _ := json.Marshal(normalizeTags([]string{tag}))
+		db = db.Where("tags @> ?::jsonb", string(tags))
+	}
+
 	var blogs []models.Blog
-	result := database.DB.Preload("Author").Find(&blogs)
+	result := db.Find(&blogs)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching blogs"})
+		apierror.Internal(c, "Error fetching blogs")
 		return
 	}
 
-	c.JSON(http.StatusOK, blogs)
+	respondWithETag(c, http.StatusOK, blogs)
+}
+
+// GetMemberBlogs returns all blogs a member wrote or co-authored
+func GetMemberBlogs(c *gin.Context) {
+	memberID, ok := parseID(c, "member")
+	if !ok {
+		return
+	}
+
+	var member models.Member
+	if err := database.DB.First(&member, "id = ?", memberID).Error; err != nil {
+		apierror.NotFound(c, "Member not found")
+		return
+	}
+
+	var blogs []models.Blog
+	result := database.DB.Scopes(withAuthors).
+		Where("id IN (?)", database.DB.Model(&models.BlogAuthor{}).
+			Select("blog_id").
+			Where("member_id = ?", memberID)).
+		Order("created_at DESC").
+		Find(&blogs)
+	if result.Error != nil {
+		apierror.Internal(c, "Error fetching blogs")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, blogs)
 }
 
 // GetBlog returns a specific blog with its author
 func GetBlog(c *gin.Context) {
-	id := c.Param("id")
-
-	// Parse UUID
-	blogID, err := uuid.Parse(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Invalid blog ID format: %s", id),
-		})
+	blogID, ok := parseID(c, "blog")
+	if !ok {
 		return
 	}
 
 	var blog models.Blog
 	// Use First to get a single record, respecting soft deletes
-	if err := database.DB.Preload("Author").First(&blog, "id = ?", blogID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("Blog not found with ID: %s", id),
-		})
+	if err := database.DB.Scopes(withAuthors).First(&blog, "id = ?", blogID).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Blog not found with ID: %s", blogID))
 		return
 	}
 
-	c.JSON(http.StatusOK, blog)
+	recordView(c, models.ContentTypeBlog, blog.ID)
+	respondWithETag(c, http.StatusOK, blog)
 }
 
 // CreateBlog creates a new blog
 func CreateBlog(c *gin.Context) {
-	var blog models.Blog
-	if err := c.ShouldBindJSON(&blog); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req BlogRequest
+	if !bindJSON(c, &req) || !checkBlogRequest(c, &req) {
 		return
 	}
 
-	blog.ID = uuid.New()
-	if err := database.DB.Create(&blog).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating blog"})
-		return
-	}
-
-	// Fetch the complete blog with author details
-	if err := database.DB.Preload("Author").First(&blog, "id = ?", blog.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching created blog"})
+	blog := models.Blog{ID: uuid.New()}
+	req.apply(&blog)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Authors").Create(&blog).Error; err != nil {
+			return err
+		}
+		if err := syncBlogAuthors(tx, &blog, req.CoAuthorIDs); err != nil {
+			return err
+		}
+		// Fetch the complete blog with author details
+		if err := tx.Scopes(withAuthors).First(&blog, "id = ?", blog.ID).Error; err != nil {
+			return err
+		}
+		return events.Publish(tx, events.BlogPublished, events.NewBlogData(&blog))
+	})
+	if err != nil {
+		apierror.Internal(c, "Error creating blog")
 		return
 	}
 
-	c.JSON(http.StatusCreated, blog)
+	respondWithETag(c, http.StatusCreated, blog)
 }
 
-// UpdateBlog updates an existing blog
+// UpdateBlog replaces an existing blog
 func UpdateBlog(c *gin.Context) {
-	id := c.Param("id")
+	blogID, ok := parseID(c, "blog")
+	if !ok {
+		return
+	}
+
 	var blog models.Blog
+	if err := database.DB.Scopes(withAuthors).First(&blog, "id = ?", blogID).Error; err != nil {
+		apierror.NotFound(c, "Blog not found")
+		return
+	}
+	if !checkIfMatch(c, blog) {
+		return
+	}
+
+	var req BlogRequest
+	if !bindJSON(c, &req) || !checkBlogRequest(c, &req) {
+		return
+	}
 
-	if err := database.DB.First(&blog, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+	saveBlog(c, &blog, &req)
+}
+
+// PatchBlog partially updates a blog using JSON Merge Patch
+func PatchBlog(c *gin.Context) {
+	blogID, ok := parseID(c, "blog")
+	if !ok {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&blog); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var blog models.Blog
+	if err := database.DB.Scopes(withAuthors).First(&blog, "id = ?", blogID).Error; err != nil {
+		apierror.NotFound(c, "Blog not found")
+		return
+	}
+	if !checkIfMatch(c, blog) {
 		return
 	}
 
-	if err := database.DB.Save(&blog).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating blog"})
+	req, err := newBlogRequest(&blog)
+	if err != nil {
+		apierror.Internal(c, "Error loading blog authors")
+		return
+	}
+	if !bindMergePatch(c, &req) || !checkBlogRequest(c, &req) {
 		return
 	}
 
-	// Fetch the updated blog with author details
-	if err := database.DB.Preload("Author").First(&blog, "id = ?", blog.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching updated blog"})
+	saveBlog(c, &blog, &req)
+}
+
+// saveBlog applies a validated request to a blog and writes the result
+func saveBlog(c *gin.Context, blog *models.Blog, req *BlogRequest) {
+	req.apply(blog)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, blog, &blog.Version); err != nil {
+			return err
+		}
+		if err := syncBlogAuthors(tx, blog, req.CoAuthorIDs); err != nil {
+			return err
+		}
+		// Fetch the updated blog with author details
+		if err := tx.Scopes(withAuthors).First(blog, "id = ?", blog.ID).Error; err != nil {
+			return err
+		}
+		return events.Publish(tx, events.BlogUpdated, events.NewBlogData(blog))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error updating blog")
 		return
 	}
 
-	c.JSON(http.StatusOK, blog)
+	respondWithETag(c, http.StatusOK, blog)
 }
 
 // DeleteBlog deletes a blog
 func DeleteBlog(c *gin.Context) {
-	id := c.Param("id")
-
-	// Parse UUID
-	blogID, err := uuid.Parse(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Invalid blog ID format: %s", id),
-		})
+	blogID, ok := parseID(c, "blog")
+	if !ok {
 		return
 	}
 
 	// First check if the blog exists
 	var blog models.Blog
-	if err := database.DB.First(&blog, "id = ?", blogID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("Blog not found with ID: %s", id),
-		})
+	if err := database.DB.Scopes(withAuthors).First(&blog, "id = ?", blogID).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Blog not found with ID: %s", blogID))
+		return
+	}
+	if !checkIfMatch(c, blog) {
 		return
 	}
 
-	// Hard delete the blog
-	if err := database.DB.Unscoped().Delete(&blog, "id = ?", blogID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Error deleting blog: %v", err),
-		})
+	// Soft delete the blog; it stays restorable from the trash
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteVersioned(tx, &blog, blog.Version); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.BlogDeleted, events.NewBlogData(&blog))
+	})
+	if err != nil {
+		respondWriteError(c, err, fmt.Sprintf("Error deleting blog: %v", err))
 		return
 	}
 
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/ical"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// calendarHistory is how long ended and deleted events stay in the feeds, so
+// subscribers see past events and pick up cancellations
+const calendarHistory = 90 * 24 * time.Hour
+
+// calendarContentType is the media type of iCalendar documents
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// calendarTimezone returns the club's IANA timezone from CLUB_TIMEZONE,
+// falling back to UTC when it is missing or unknown
+func calendarTimezone() string {
+	tz := os.Getenv("CLUB_TIMEZONE")
+	if _, err := time.LoadLocation(tz); tz == "" || err != nil {
+		return "UTC"
+	}
+	return tz
+}
+
+// toCalendarEvent converts an event, possibly soft deleted, to a VEVENT.
+// Deleted events are published as cancelled with a higher sequence.
+func toCalendarEvent(event *models.Event) ical.Event {
+	domain := os.Getenv("CALENDAR_DOMAIN")
+	if domain == "" {
+		domain = "avions-club"
+	}
+
+	entry := ical.Event{
+		UID:          fmt.Sprintf("%s@%s", event.ID, domain),
+		Sequence:     event.Version - 1,
+		Summary:      event.Title,
+		Description:  event.Description,
+		Location:     event.Location,
+		Categories:   append([]string{event.Kind}, event.Tags...),
+		Start:        event.StartsAt,
+		End:          event.EndsAt,
+		Created:      event.CreatedAt,
+		LastModified: event.UpdatedAt,
+		Cancelled:    event.Status == models.EventStatusCancelled,
+	}
+	if siteURL := os.Getenv("SITE_URL"); siteURL != "" {
+		entry.URL = fmt.Sprintf("%s/events/%s", strings.TrimRight(siteURL, "/"), event.ID)
+	}
+	if event.DeletedAt.Valid {
+		entry.Sequence = event.Version
+		entry.LastModified = event.DeletedAt.Time
+		entry.Cancelled = true
+	}
+	return entry
+}
+
+// respondWithCalendar renders events as an iCalendar feed
+func respondWithCalendar(c *gin.Context, name string, db *gorm.DB) {
+	since := time.Now().Add(-calendarHistory)
+	var events []models.Event
+	if err := db.Unscoped().
+		Where("ends_at >= ?", since).
+		Where("deleted_at IS NULL OR deleted_at >= ?", since).
+		Order("starts_at").
+		Find(&events).Error; err != nil {
+		apierror.Internal(c, "Error fetching events")
+		return
+	}
+
+	calendar := ical.Calendar{Name: name, Timezone: calendarTimezone()}
+	for i := range events {
+		calendar.Events = append(calendar.Events, toCalendarEvent(&events[i]))
+	}
+
+	respondBodyWithETag(c, http.StatusOK, calendarContentType, calendar.Bytes())
+}
+
+// GetCalendar returns the iCalendar feed of all club events
+func GetCalendar(c *gin.Context) {
+	respondWithCalendar(c, "Avions Club", database.DB)
+}
+
+// GetTagCalendar returns the iCalendar feed of events with a tag. The tag
+// may carry an .ics extension so calendar apps recognise the URL.
+func GetTagCalendar(c *gin.Context) {
+	tag := strings.TrimSuffix(c.Param("tag"), ".ics")
+	tags, _ := json.Marshal(normalizeTags([]string{tag}))
+	if string(tags) == "[]" {
+		apierror.BadRequest(c, "Tag is required")
+		return
+	}
+
+	respondWithCalendar(c, fmt.Sprintf("Avions Club - %s", tag),
+		database.DB.Where("tags @> ?::jsonb", string(tags)))
+}
+
+// GetEventCalendar returns a single event as an .ics download
+func GetEventCalendar(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) {
+		return
+	}
+
+	calendar := ical.Calendar{
+		Name:     event.Title,
+		Timezone: calendarTimezone(),
+		Events:   []ical.Event{toCalendarEvent(&event)},
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="event-%s.ics"`, event.ID))
+	respondBodyWithETag(c, http.StatusOK, calendarContentType, calendar.Bytes())
+}
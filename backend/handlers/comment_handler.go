@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/middleware"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentRequest is the body of the public comment form. Guests give a name
+// and email; admins may post on behalf of a member with memberId instead.
+// Website is a honeypot, see honeypotTripped.
+type CommentRequest struct {
+	Name     string     `json:"name" binding:"max=255"`
+	Email    string     `json:"email" binding:"omitempty,email,max=255"`
+	MemberID *uuid.UUID `json:"memberId"`
+	ParentID *uuid.UUID `json:"parentId"`
+	Body     string     `json:"body" binding:"required,min=2,max=5000"`
+	Website  string     `json:"website"`
+}
+
+// CommentStatusRequest moderates a comment
+type CommentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected spam"`
+}
+
+// checkCommentRequest verifies the author and parent of a decoded comment
+// request. On failure it writes the error envelope and returns false.
+func checkCommentRequest(c *gin.Context, blog *models.Blog, req *CommentRequest) bool {
+	var fields []apierror.FieldError
+	if req.MemberID != nil {
+		if !middleware.IsAdmin(c) {
+			apierror.Forbidden(c, "Only admins can comment on behalf of a member")
+			return false
+		}
+		memberFields, err := missingIDField(&models.Member{}, "memberId", req.MemberID, "must reference an existing member")
+		if err != nil {
+			apierror.Internal(c, "Error checking comment member")
+			return false
+		}
+		fields = append(fields, memberFields...)
+	} else {
+		if strings.TrimSpace(req.Name) == "" {
+			fields = append(fields, apierror.FieldError{Field: "name", Code: "required", Message: "is required"})
+		}
+		if req.Email == "" {
+			fields = append(fields, apierror.FieldError{Field: "email", Code: "required", Message: "is required"})
+		}
+	}
+
+	if req.ParentID != nil {
+		var parent models.Comment
+		err := database.DB.Select("id").
+			Where("blog_id = ? AND parent_id IS NULL AND status = ?", blog.ID, models.CommentStatusApproved).
+			First(&parent, "id = ?", *req.ParentID).Error
+		if err != nil {
+			fields = append(fields, apierror.FieldError{
+				Field:   "parentId",
+				Code:    "not_found",
+				Message: "must reference a published top-level comment on this blog",
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// hideCommenterDetails drops the email, IP address and user agent of
+// commenters unless the request comes from an admin
+func hideCommenterDetails(c *gin.Context, comments []models.Comment) {
+	if middleware.AdminView(c) {
+		return
+	}
+	for i := range comments {
+		comments[i].Email = ""
+		comments[i].IPAddress = ""
+		comments[i].UserAgent = ""
+		hideCommenterDetails(c, comments[i].Replies)
+	}
+}
+
+// findCommentBlog loads the blog of the :id path parameter. On failure it
+// writes the error envelope and returns false.
+func findCommentBlog(c *gin.Context, blog *models.Blog) bool {
+	id, ok := parseID(c, "blog")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.First(blog, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Blog not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// GetBlogComments returns the approved comments of a blog, oldest first, with
+// their approved replies
+func GetBlogComments(c *gin.Context) {
+	var blog models.Blog
+	if !findCommentBlog(c, &blog) {
+		return
+	}
+
+	var comments []models.Comment
+	err := database.DB.Preload("Member").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", models.CommentStatusApproved).Order("created_at")
+		}).
+		Preload("Replies.Member").
+		Where("blog_id = ? AND parent_id IS NULL AND status = ?", blog.ID, models.CommentStatusApproved).
+		Order("created_at").
+		Find(&comments).Error
+	if err != nil {
+		apierror.Internal(c, "Error fetching comments")
+		return
+	}
+	hideCommenterDetails(c, comments)
+
+	respondWithETag(c, http.StatusOK, comments)
+}
+
+// CreateComment posts a comment on a blog. Guest comments wait in the
+// moderation queue, and go straight to spam when the same email or IP
+// address was already flagged; comments posted by admins are published
+// immediately.
+func CreateComment(c *gin.Context) {
+	var blog models.Blog
+	if !findCommentBlog(c, &blog) {
+		return
+	}
+
+	var req CommentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	pending := gin.H{"message": "Comment received; it will appear once approved"}
+	if honeypotTripped(c, req.Website, http.StatusAccepted, pending) {
+		return
+	}
+	if blog.CommentsClosed {
+		apierror.Forbidden(c, "Comments are closed on this blog")
+		return
+	}
+	if !checkCommentRequest(c, &blog, &req) {
+		return
+	}
+
+	comment := models.Comment{
+		BlogID:    blog.ID,
+		ParentID:  req.ParentID,
+		Name:      strings.TrimSpace(req.Name),
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Body:      strings.TrimSpace(req.Body),
+		Status:    models.CommentStatusPending,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if req.MemberID != nil {
+		var member models.Member
+		if err := database.DB.First(&member, "id = ?", *req.MemberID).Error; err != nil {
+			apierror.Internal(c, "Error fetching comment member")
+			return
+		}
+		comment.MemberID = &member.ID
+		comment.Name = member.Name
+	}
+
+	now := time.Now()
+	if middleware.IsAdmin(c) {
+		comment.Status = models.CommentStatusApproved
+		comment.ModeratedAt = &now
+	} else {
+		var flagged int64
+		if err := database.DB.Model(&models.Comment{}).
+			Where("status = ? AND (email = ? OR ip_address = ?)",
+				models.CommentStatusSpam, comment.Email, comment.IPAddress).
+			Count(&flagged).Error; err != nil {
+			apierror.Internal(c, "Error checking comment history")
+			return
+		}
+		if flagged > 0 {
+			comment.Status = models.CommentStatusSpam
+			comment.ModeratedAt = &now
+		}
+	}
+
+	if err := database.DB.Create(&comment).Error; err != nil {
+		apierror.Internal(c, "Error posting comment")
+		return
+	}
+
+	if comment.Status != models.CommentStatusApproved {
+		c.JSON(http.StatusAccepted, pending)
+		return
+	}
+	if err := database.DB.Preload("Member").First(&comment, "id = ?", comment.ID).Error; err != nil {
+		apierror.Internal(c, "Error fetching comment")
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// GetComments returns the moderation queue, oldest first. The status query
+// parameter defaults to pending comments; "all" lists every comment. blogId
+// filters.
+func GetComments(c *gin.Context) {
+	db := database.DB.Preload("Member").Order("created_at")
+	switch status := c.DefaultQuery("status", models.CommentStatusPending); status {
+	case "all":
+	case models.CommentStatusPending, models.CommentStatusApproved,
+		models.CommentStatusRejected, models.CommentStatusSpam:
+		db = db.Where("status = ?", status)
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: all, pending, approved, rejected, spam",
+		}})
+		return
+	}
+
+	if blogID := c.Query("blogId"); blogID != "" {
+		id, err := uuid.Parse(blogID)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid blog ID format: %s", blogID))
+			return
+		}
+		db = db.Where("blog_id = ?", id)
+	}
+
+	var comments []models.Comment
+	if err := db.Find(&comments).Error; err != nil {
+		apierror.Internal(c, "Error fetching comments")
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// findComment loads a comment by the :id path parameter. On failure it
+// writes the error envelope and returns false.
+func findComment(c *gin.Context, comment *models.Comment) bool {
+	id, ok := parseID(c, "comment")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Preload("Member").First(comment, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Comment not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// UpdateCommentStatus approves a comment, or rejects it or marks it as spam
+func UpdateCommentStatus(c *gin.Context) {
+	var comment models.Comment
+	if !findComment(c, &comment) {
+		return
+	}
+
+	var req CommentStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	now := time.Now()
+	comment.Status = req.Status
+	comment.ModeratedAt = &now
+	if err := database.DB.Model(&comment).Updates(map[string]any{
+		"status":       comment.Status,
+		"moderated_at": comment.ModeratedAt,
+	}).Error; err != nil {
+		apierror.Internal(c, "Error moderating comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment deletes a comment along with its replies
+func DeleteComment(c *gin.Context) {
+	var comment models.Comment
+	if !findComment(c, &comment) {
+		return
+	}
+
+	if err := database.DB.Delete(&comment).Error; err != nil {
+		apierror.Internal(c, "Error deleting comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// achievementOrder lists the latest achievements first, best placement first
+// within a year
+const achievementOrder = `(SELECT year FROM competitions WHERE competitions.id = achievements.competition_id) DESC,
+	placement NULLS LAST, award`
+
+// CompetitionRequest is the body accepted when creating or replacing a competition
+type CompetitionRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Organizer   string `json:"organizer" binding:"max=255"`
+	Category    string `json:"category" binding:"required,oneof=aero_design drone_racing uav_challenge other"`
+	Year        int    `json:"year" binding:"required,min=1900,max=2100"`
+	Location    string `json:"location" binding:"max=255"`
+	Description string `json:"description" binding:"max=10000"`
+	WebsiteURL  string `json:"websiteUrl" binding:"omitempty,max=2048,http_url"`
+	ImageURL    string `json:"imageUrl" binding:"omitempty,max=2048,storageurl=images"`
+}
+
+// newCompetitionRequest returns the request that would recreate a competition as it is
+func newCompetitionRequest(competition *models.Competition) CompetitionRequest {
+	return CompetitionRequest{
+		Name:        competition.Name,
+		Organizer:   competition.Organizer,
+		Category:    competition.Category,
+		Year:        competition.Year,
+		Location:    competition.Location,
+		Description: competition.Description,
+		WebsiteURL:  competition.WebsiteURL,
+		ImageURL:    competition.ImageURL,
+	}
+}
+
+// apply copies the request onto a competition
+func (r *CompetitionRequest) apply(competition *models.Competition) {
+	competition.Name = r.Name
+	competition.Organizer = r.Organizer
+	competition.Category = r.Category
+	competition.Year = r.Year
+	competition.Location = r.Location
+	competition.Description = r.Description
+	competition.WebsiteURL = r.WebsiteURL
+	competition.ImageURL = r.ImageURL
+}
+
+// AchievementRequest is the body accepted when creating or replacing an achievement
+type AchievementRequest struct {
+	CompetitionID uuid.UUID   `json:"competitionId" binding:"required"`
+	ProjectID     *uuid.UUID  `json:"projectId"`
+	Team          string      `json:"team" binding:"max=255"`
+	Placement     *int        `json:"placement" binding:"omitempty,min=1,max=1000"`
+	Award         string      `json:"award" binding:"max=255"`
+	Description   string      `json:"description" binding:"max=5000"`
+	EvidenceURLs  []string    `json:"evidenceUrls" binding:"omitempty,max=20,dive,required,max=2048,storageurl=images"`
+	MemberIDs     []uuid.UUID `json:"memberIds" binding:"omitempty,max=50,dive,required"`
+}
+
+// newAchievementRequest returns the request that would recreate an achievement as it is
+func newAchievementRequest(achievement *models.Achievement) AchievementRequest {
+	req := AchievementRequest{
+		CompetitionID: achievement.CompetitionID,
+		ProjectID:     achievement.ProjectID,
+		Team:          achievement.Team,
+		Placement:     achievement.Placement,
+		Award:         achievement.Award,
+		Description:   achievement.Description,
+		EvidenceURLs:  achievement.EvidenceURLs,
+	}
+	for _, member := range achievement.Members {
+		req.MemberIDs = append(req.MemberIDs, member.ID)
+	}
+	return req
+}
+
+// apply copies the request onto an achievement
+func (r *AchievementRequest) apply(achievement *models.Achievement) {
+	achievement.CompetitionID = r.CompetitionID
+	achievement.ProjectID = r.ProjectID
+	achievement.Team = r.Team
+	achievement.Placement = r.Placement
+	achievement.Award = r.Award
+	achievement.Description = r.Description
+	achievement.EvidenceURLs = r.EvidenceURLs
+	if achievement.EvidenceURLs == nil {
+		achievement.EvidenceURLs = []string{}
+	}
+}
+
+// checkAchievementRequest verifies the competition, project and members of a
+// decoded achievement request. On failure it writes the error envelope and
+// returns false.
+func checkAchievementRequest(c *gin.Context, req *AchievementRequest) bool {
+	competitions, err := existingIDs(&models.Competition{}, []uuid.UUID{req.CompetitionID})
+	if err != nil {
+		apierror.Internal(c, "Error checking achievement competition")
+		return false
+	}
+	members, err := existingIDs(&models.Member{}, req.MemberIDs)
+	if err != nil {
+		apierror.Internal(c, "Error checking achievement members")
+		return false
+	}
+
+	var fields []apierror.FieldError
+	if !competitions[req.CompetitionID] {
+		fields = append(fields, apierror.FieldError{
+			Field:   "competitionId",
+			Code:    "not_found",
+			Message: "must reference an existing competition",
+		})
+	}
+	if req.ProjectID != nil {
+		projects, err := existingIDs(&models.Project{}, []uuid.UUID{*req.ProjectID})
+		if err != nil {
+			apierror.Internal(c, "Error checking achievement project")
+			return false
+		}
+		if !projects[*req.ProjectID] {
+			fields = append(fields, apierror.FieldError{
+				Field:   "projectId",
+				Code:    "not_found",
+				Message: "must reference an existing project",
+			})
+		}
+	}
+	if req.Placement == nil && req.Award == "" {
+		fields = append(fields, apierror.FieldError{
+			Field:   "award",
+			Code:    "required",
+			Message: "is required when there is no placement",
+		})
+	}
+	fields = append(fields, missingIDFields("memberIds", req.MemberIDs, members, "must reference an existing member")...)
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// listedAchievements limits achievements to competitions that are not deleted
+func listedAchievements(db *gorm.DB) *gorm.DB {
+	return db.Where("competition_id IN (?)", database.DB.Model(&models.Competition{}).Select("id"))
+}
+
+// withAchievementDetails preloads the competition, project and members of
+// an achievement
+func withAchievementDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Competition").Preload("Project").Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	})
+}
+
+// withCompetitionAchievements preloads the achievements of a competition
+// with their projects and members
+func withCompetitionAchievements(db *gorm.DB) *gorm.DB {
+	return db.Preload("Achievements", func(db *gorm.DB) *gorm.DB {
+		return db.Order("placement NULLS LAST, award")
+	}).Preload("Achievements.Project").Preload("Achievements.Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	})
+}
+
+// findCompetition loads a competition with its achievements by the :id path
+// parameter. On failure it writes the error envelope and returns false.
+func findCompetition(c *gin.Context, competition *models.Competition) bool {
+	id, ok := parseID(c, "competition")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withCompetitionAchievements).First(competition, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Competition not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// respondWithCompetition reloads a competition after a write so the response
+// and its ETag match what a subsequent GET returns
+func respondWithCompetition(c *gin.Context, status int, id uuid.UUID) {
+	var competition models.Competition
+	if err := database.DB.Scopes(withCompetitionAchievements).First(&competition, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching competition")
+		return
+	}
+
+	respondWithETag(c, status, competition)
+}
+
+// parseYearQuery reads the optional year query parameter. On failure it
+// writes the error envelope and returns false.
+func parseYearQuery(c *gin.Context) (int, bool) {
+	raw := c.Query("year")
+	if raw == "" {
+		return 0, true
+	}
+	year, err := strconv.Atoi(raw)
+	if err != nil {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "year",
+			Code:    "invalid_type",
+			Message: "must be a year",
+		}})
+		return 0, false
+	}
+	return year, true
+}
+
+// GetCompetitions returns competitions with their achievements, latest first.
+// year and category filter.
+func GetCompetitions(c *gin.Context) {
+	db := database.DB.Scopes(withCompetitionAchievements).Order("year DESC, name")
+	year, ok := parseYearQuery(c)
+	if !ok {
+		return
+	}
+	if year != 0 {
+		db = db.Where("year = ?", year)
+	}
+	if category := c.Query("category"); category != "" {
+		db = db.Where("category = ?", category)
+	}
+
+	var competitions []models.Competition
+	if err := db.Find(&competitions).Error; err != nil {
+		apierror.Internal(c, "Error fetching competitions")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, competitions)
+}
+
+// GetCompetition returns a specific competition
+func GetCompetition(c *gin.Context) {
+	var competition models.Competition
+	if !findCompetition(c, &competition) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, competition)
+}
+
+// CreateCompetition creates a new competition
+func CreateCompetition(c *gin.Context) {
+	var req CompetitionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	competition := models.Competition{ID: uuid.New()}
+	req.apply(&competition)
+	if err := database.DB.Omit(clause.Associations).Create(&competition).Error; err != nil {
+		apierror.Internal(c, "Error creating competition")
+		return
+	}
+
+	respondWithCompetition(c, http.StatusCreated, competition.ID)
+}
+
+// saveCompetition applies a validated request to a competition and writes the result
+func saveCompetition(c *gin.Context, competition *models.Competition, req *CompetitionRequest) {
+	req.apply(competition)
+	if err := saveVersioned(database.DB, competition, &competition.Version); err != nil {
+		respondWriteError(c, err, "Error updating competition")
+		return
+	}
+
+	respondWithCompetition(c, http.StatusOK, competition.ID)
+}
+
+// UpdateCompetition replaces an existing competition
+func UpdateCompetition(c *gin.Context) {
+	var competition models.Competition
+	if !findCompetition(c, &competition) || !checkIfMatch(c, competition) {
+		return
+	}
+
+	var req CompetitionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	saveCompetition(c, &competition, &req)
+}
+
+// PatchCompetition partially updates a competition using JSON Merge Patch
+func PatchCompetition(c *gin.Context) {
+	var competition models.Competition
+	if !findCompetition(c, &competition) || !checkIfMatch(c, competition) {
+		return
+	}
+
+	req := newCompetitionRequest(&competition)
+	if !bindMergePatch(c, &req) {
+		return
+	}
+
+	saveCompetition(c, &competition, &req)
+}
+
+// DeleteCompetition deletes a competition, which hides its achievements
+func DeleteCompetition(c *gin.Context) {
+	var competition models.Competition
+	if !findCompetition(c, &competition) || !checkIfMatch(c, competition) {
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &competition, competition.Version); err != nil {
+		respondWriteError(c, err, "Error deleting competition")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Competition deleted successfully"})
+}
+
+// findAchievement loads an achievement with its details by the :id path
+// parameter. On failure it writes the error envelope and returns false.
+func findAchievement(c *gin.Context, achievement *models.Achievement) bool {
+	id, ok := parseID(c, "achievement")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withAchievementDetails, listedAchievements).First(achievement, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Achievement not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// respondWithAchievement reloads an achievement after a write so the response
+// and its ETag match what a subsequent GET returns
+func respondWithAchievement(c *gin.Context, status int, id uuid.UUID) {
+	var achievement models.Achievement
+	if err := database.DB.Scopes(withAchievementDetails).First(&achievement, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching achievement")
+		return
+	}
+
+	respondWithETag(c, status, achievement)
+}
+
+// GetAchievements returns achievements, latest first. year, competitionId,
+// projectId and memberId filter.
+func GetAchievements(c *gin.Context) {
+	db := database.DB.Scopes(withAchievementDetails, listedAchievements).Order(achievementOrder)
+	year, ok := parseYearQuery(c)
+	if !ok {
+		return
+	}
+	if year != 0 {
+		db = db.Where("competition_id IN (?)", database.DB.Model(&models.Competition{}).
+			Select("id").
+			Where("year = ?", year))
+	}
+
+	for _, filter := range []struct{ param, column string }{
+		{"competitionId", "competition_id"},
+		{"projectId", "project_id"},
+	} {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid %s format: %s", filter.param, value))
+			return
+		}
+		db = db.Where(filter.column+" = ?", id)
+	}
+	if memberID := c.Query("memberId"); memberID != "" {
+		id, err := uuid.Parse(memberID)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid member ID format: %s", memberID))
+			return
+		}
+		db = db.Where("id IN (?)", database.DB.Table("achievement_members").
+			Select("achievement_id").
+			Where("member_id = ?", id))
+	}
+
+	var achievements []models.Achievement
+	if err := db.Find(&achievements).Error; err != nil {
+		apierror.Internal(c, "Error fetching achievements")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, achievements)
+}
+
+// GetAchievement returns a specific achievement
+func GetAchievement(c *gin.Context) {
+	var achievement models.Achievement
+	if !findAchievement(c, &achievement) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, achievement)
+}
+
+// saveAchievementMembers replaces the members credited with an achievement
+func saveAchievementMembers(tx *gorm.DB, achievement *models.Achievement, memberIDs []uuid.UUID) error {
+	members := make([]models.Member, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		members = append(members, models.Member{ID: id})
+	}
+	return tx.Model(achievement).Omit("Members.*").Association("Members").Replace(members)
+}
+
+// CreateAchievement records a new achievement
+func CreateAchievement(c *gin.Context) {
+	var req AchievementRequest
+	if !bindJSON(c, &req) || !checkAchievementRequest(c, &req) {
+		return
+	}
+
+	achievement := models.Achievement{ID: uuid.New()}
+	req.apply(&achievement)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&achievement).Error; err != nil {
+			return err
+		}
+		return saveAchievementMembers(tx, &achievement, req.MemberIDs)
+	})
+	if err != nil {
+		apierror.Internal(c, "Error creating achievement")
+		return
+	}
+
+	respondWithAchievement(c, http.StatusCreated, achievement.ID)
+}
+
+// saveAchievement applies a validated request to an achievement and writes the result
+func saveAchievement(c *gin.Context, achievement *models.Achievement, req *AchievementRequest) {
+	req.apply(achievement)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, achievement, &achievement.Version); err != nil {
+			return err
+		}
+		return saveAchievementMembers(tx, achievement, req.MemberIDs)
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error updating achievement")
+		return
+	}
+
+	respondWithAchievement(c, http.StatusOK, achievement.ID)
+}
+
+// UpdateAchievement replaces an existing achievement
+func UpdateAchievement(c *gin.Context) {
+	var achievement models.Achievement
+	if !findAchievement(c, &achievement) || !checkIfMatch(c, achievement) {
+		return
+	}
+
+	var req AchievementRequest
+	if !bindJSON(c, &req) || !checkAchievementRequest(c, &req) {
+		return
+	}
+
+	saveAchievement(c, &achievement, &req)
+}
+
+// PatchAchievement partially updates an achievement using JSON Merge Patch
+func PatchAchievement(c *gin.Context) {
+	var achievement models.Achievement
+	if !findAchievement(c, &achievement) || !checkIfMatch(c, achievement) {
+		return
+	}
+
+	req := newAchievementRequest(&achievement)
+	if !bindMergePatch(c, &req) || !checkAchievementRequest(c, &req) {
+		return
+	}
+
+	saveAchievement(c, &achievement, &req)
+}
+
+// DeleteAchievement deletes an achievement
+func DeleteAchievement(c *gin.Context) {
+	var achievement models.Achievement
+	if !findAchievement(c, &achievement) || !checkIfMatch(c, achievement) {
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &achievement, achievement.Version); err != nil {
+		respondWriteError(c, err, "Error deleting achievement")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Achievement deleted successfully"})
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/mailer"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxContactLinks is the most links a contact message may contain; link
+// lists are the usual sign of spam
+const maxContactLinks = 3
+
+// linkPattern finds links in a contact message
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// ContactFormRequest is the body of the public contact form. Website is a
+// honeypot, see honeypotTripped.
+type ContactFormRequest struct {
+	Name         string `json:"name" binding:"required,max=255"`
+	Email        string `json:"email" binding:"required,email,max=255"`
+	Organization string `json:"organization" binding:"max=255"`
+	Topic        string `json:"topic" binding:"omitempty,oneof=general sponsorship membership media other"`
+	Subject      string `json:"subject" binding:"required,max=255"`
+	Message      string `json:"message" binding:"required,min=20,max=5000"`
+	Website      string `json:"website"`
+}
+
+// ContactStatusRequest files a message in the admin inbox
+type ContactStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=new read archived"`
+}
+
+// forwardContactMessage emails a contact message to CONTACT_EMAIL with the
+// sender as Reply-To and records when it was forwarded. Nothing is sent when
+// CONTACT_EMAIL is not set.
+func forwardContactMessage(message *models.ContactMessage) error {
+	to := os.Getenv("CONTACT_EMAIL")
+	if to == "" {
+		return nil
+	}
+
+	sender := mail.Address{Name: message.Name, Address: message.Email}
+	text := fmt.Sprintf("From: %s\nOrganization: %s\nTopic: %s\nReceived: %s\n\n%s\n",
+		sender.String(), message.Organization, message.Topic,
+		message.CreatedAt.UTC().Format(time.RFC1123), message.Message)
+	if err := mailer.Send(mailer.Message{
+		To:      to,
+		Subject: fmt.Sprintf("[Contact: %s] %s", message.Topic, message.Subject),
+		Text:    text,
+		Headers: map[string]string{"Reply-To": sender.String()},
+	}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	message.ForwardedAt = &now
+	return database.DB.Model(message).Update("forwarded_at", now).Error
+}
+
+// SubmitContact stores a message from the public contact form and forwards
+// it to the club by email in the background
+func SubmitContact(c *gin.Context) {
+	var req ContactFormRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	accepted := gin.H{"message": "Thanks for getting in touch; we will reply by email"}
+	if honeypotTripped(c, req.Website, http.StatusAccepted, accepted) {
+		return
+	}
+	var fields []apierror.FieldError
+	// The name and subject end up in mail headers
+	for _, line := range []struct{ field, value string }{
+		{"name", req.Name},
+		{"subject", req.Subject},
+	} {
+		if strings.ContainsAny(line.value, "\r\n") {
+			fields = append(fields, apierror.FieldError{
+				Field:   line.field,
+				Code:    "invalid",
+				Message: "must be a single line",
+			})
+		}
+	}
+	if len(linkPattern.FindAllStringIndex(req.Message, -1)) > maxContactLinks {
+		fields = append(fields, apierror.FieldError{
+			Field:   "message",
+			Code:    "invalid",
+			Message: fmt.Sprintf("must contain at most %d links", maxContactLinks),
+		})
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return
+	}
+
+	message := models.ContactMessage{
+		Name:         strings.TrimSpace(req.Name),
+		Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+		Organization: strings.TrimSpace(req.Organization),
+		Topic:        req.Topic,
+		Subject:      strings.TrimSpace(req.Subject),
+		Message:      strings.TrimSpace(req.Message),
+		Status:       models.ContactStatusNew,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	}
+	if message.Topic == "" {
+		message.Topic = "general"
+	}
+	if err := database.DB.Create(&message).Error; err != nil {
+		apierror.Internal(c, "Error sending message")
+		return
+	}
+
+	go func() {
+		if err := forwardContactMessage(&message); err != nil {
+			log.Printf("Error forwarding contact message %s: %v", message.ID, err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// GetContactMessages returns the admin inbox, newest first. status defaults
+// to new and read messages; "all" lists every message. topic filters.
+func GetContactMessages(c *gin.Context) {
+	db := database.DB.Order("created_at DESC")
+	switch status := c.Query("status"); status {
+	case "":
+		db = db.Where("status IN ?", []string{models.ContactStatusNew, models.ContactStatusRead})
+	case "all":
+	case models.ContactStatusNew, models.ContactStatusRead, models.ContactStatusArchived:
+		db = db.Where("status = ?", status)
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: all, new, read, archived",
+		}})
+		return
+	}
+	if topic := c.Query("topic"); topic != "" {
+		db = db.Where("topic = ?", topic)
+	}
+
+	var messages []models.ContactMessage
+	if err := db.Find(&messages).Error; err != nil {
+		apierror.Internal(c, "Error fetching messages")
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// findContactMessage loads a contact message by the :id path parameter. On
+// failure it writes the error envelope and returns false.
+func findContactMessage(c *gin.Context, message *models.ContactMessage) bool {
+	id, ok := parseID(c, "message")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.First(message, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Message not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// GetContactMessage returns a specific contact message
+func GetContactMessage(c *gin.Context) {
+	var message models.ContactMessage
+	if !findContactMessage(c, &message) {
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// UpdateContactStatus marks a contact message as new, read or archived
+func UpdateContactStatus(c *gin.Context) {
+	var message models.ContactMessage
+	if !findContactMessage(c, &message) {
+		return
+	}
+
+	var req ContactStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	message.Status = req.Status
+	if err := database.DB.Model(&message).Update("status", message.Status).Error; err != nil {
+		apierror.Internal(c, "Error updating message")
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// ForwardContactMessage emails a contact message to CONTACT_EMAIL again,
+// e.g. after the mail server was unreachable
+func ForwardContactMessage(c *gin.Context) {
+	var message models.ContactMessage
+	if !findContactMessage(c, &message) {
+		return
+	}
+	if os.Getenv("CONTACT_EMAIL") == "" {
+		apierror.Conflict(c, "CONTACT_EMAIL is not configured")
+		return
+	}
+
+	if err := forwardContactMessage(&message); err != nil {
+		log.Printf("Error forwarding contact message %s: %v", message.ID, err)
+		apierror.Internal(c, "Error forwarding message")
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// DeleteContactMessage deletes a contact message
+func DeleteContactMessage(c *gin.Context) {
+	var message models.ContactMessage
+	if !findContactMessage(c, &message) {
+		return
+	}
+
+	if err := database.DB.Delete(&message).Error; err != nil {
+		apierror.Internal(c, "Error deleting message")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"avions-club/backend/analytics"
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/middleware"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// reactionKinds lists the reactions readers can leave, in display order
+var reactionKinds = []string{
+	models.ReactionLike,
+	models.ReactionLove,
+	models.ReactionClap,
+	models.ReactionRocket,
+}
+
+// ReactionRequest is the body accepted when reacting to a blog or project
+type ReactionRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=like love clap rocket"`
+}
+
+// ContentStats is how often a blog or project was viewed and reacted to.
+// Reactions holds a count for every kind, including those nobody left.
+type ContentStats struct {
+	Views     int64            `json:"views"`
+	Reactions map[string]int64 `json:"reactions"`
+}
+
+// PopularBlog is a blog with its views over the requested period
+type PopularBlog struct {
+	Blog  models.Blog `json:"blog"`
+	Views int64       `json:"views"`
+}
+
+// PopularProject is a project with its views over the requested period
+type PopularProject struct {
+	Project models.Project `json:"project"`
+	Views   int64          `json:"views"`
+}
+
+// recordView counts a view of a blog or project. Admins, HEAD requests and
+// browser prefetches are not counted; bots and repeat visitors are filtered
+// by the tracker.
+func recordView(c *gin.Context, contentType string, id uuid.UUID) {
+	if c.Request.Method != http.MethodGet || middleware.IsAdmin(c) {
+		return
+	}
+	purpose := c.GetHeader("Sec-Purpose") + c.GetHeader("Purpose") + c.GetHeader("X-Moz")
+	if strings.Contains(strings.ToLower(purpose), "prefetch") {
+		return
+	}
+	analytics.RecordView(contentType, id, c.ClientIP(), c.Request.UserAgent())
+}
+
+// findContent checks that the blog or project of the :id path parameter
+// exists and returns its ID. On failure it writes the error envelope and
+// returns false.
+func findContent(c *gin.Context, contentType string) (uuid.UUID, bool) {
+	id, ok := parseID(c, contentType)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	var model any = &models.Blog{}
+	if contentType == models.ContentTypeProject {
+		model = &models.Project{}
+	}
+	exists, err := existingIDs(model, []uuid.UUID{id})
+	if err != nil {
+		apierror.Internal(c, fmt.Sprintf("Error fetching %s", contentType))
+		return uuid.Nil, false
+	}
+	if !exists[id] {
+		apierror.NotFound(c, fmt.Sprintf("%s not found with ID: %s", strings.ToUpper(contentType[:1])+contentType[1:], id))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// contentStats totals the views, including unflushed ones, and reactions of
+// a blog or project
+func contentStats(contentType string, id uuid.UUID) (ContentStats, error) {
+	stats := ContentStats{Reactions: make(map[string]int64, len(reactionKinds))}
+	if err := database.DB.Model(&models.ContentView{}).
+		Select("COALESCE(SUM(views), 0)").
+		Where("content_type = ? AND content_id = ?", contentType, id).
+		Scan(&stats.Views).Error; err != nil {
+		return stats, err
+	}
+	stats.Views += analytics.Pending(contentType, id)
+
+	var counts []struct {
+		Kind  string
+		Count int64
+	}
+	if err := database.DB.Model(&models.Reaction{}).
+		Select("kind, COUNT(*) AS count").
+		Where("content_type = ? AND content_id = ?", contentType, id).
+		Group("kind").
+		Scan(&counts).Error; err != nil {
+		return stats, err
+	}
+	for _, kind := range reactionKinds {
+		stats.Reactions[kind] = 0
+	}
+	for _, count := range counts {
+		stats.Reactions[count.Kind] = count.Count
+	}
+	return stats, nil
+}
+
+// getContentStats responds with the stats of the blog or project of the :id
+// path parameter
+func getContentStats(c *gin.Context, contentType string) {
+	id, ok := findContent(c, contentType)
+	if !ok {
+		return
+	}
+
+	stats, err := contentStats(contentType, id)
+	if err != nil {
+		apierror.Internal(c, "Error fetching stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetBlogStats returns the views and reactions of a blog
+func GetBlogStats(c *gin.Context) {
+	getContentStats(c, models.ContentTypeBlog)
+}
+
+// GetProjectStats returns the views and reactions of a project
+func GetProjectStats(c *gin.Context) {
+	getContentStats(c, models.ContentTypeProject)
+}
+
+// react records a visitor's reaction to the blog or project of the :id path
+// parameter. Reacting twice with the same kind has no further effect.
+func react(c *gin.Context, contentType string) {
+	id, ok := findContent(c, contentType)
+	if !ok {
+		return
+	}
+
+	var req ReactionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	userAgent := c.Request.UserAgent()
+	if !analytics.IsBot(userAgent) {
+		reaction := models.Reaction{
+			ContentType: contentType,
+			ContentID:   id,
+			Kind:        req.Kind,
+			VisitorHash: analytics.VisitorHash(c.ClientIP(), userAgent),
+		}
+		if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error; err != nil {
+			apierror.Internal(c, "Error saving reaction")
+			return
+		}
+	}
+
+	stats, err := contentStats(contentType, id)
+	if err != nil {
+		apierror.Internal(c, "Error fetching stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// unreact removes a visitor's reaction from the blog or project of the :id
+// path parameter
+func unreact(c *gin.Context, contentType string) {
+	id, ok := findContent(c, contentType)
+	if !ok {
+		return
+	}
+	if !slices.Contains(reactionKinds, c.Param("kind")) {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "kind",
+			Code:    "invalid_choice",
+			Message: "must be one of: " + strings.Join(reactionKinds, ", "),
+		}})
+		return
+	}
+
+	if err := database.DB.
+		Where("content_type = ? AND content_id = ? AND kind = ? AND visitor_hash = ?",
+			contentType, id, c.Param("kind"), analytics.VisitorHash(c.ClientIP(), c.Request.UserAgent())).
+		Delete(&models.Reaction{}).Error; err != nil {
+		apierror.Internal(c, "Error removing reaction")
+		return
+	}
+
+	stats, err := contentStats(contentType, id)
+	if err != nil {
+		apierror.Internal(c, "Error fetching stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ReactToBlog records a reaction to a blog
+func ReactToBlog(c *gin.Context) {
+	react(c, models.ContentTypeBlog)
+}
+
+// RemoveBlogReaction removes the visitor's reaction of the :kind path parameter from a blog
+func RemoveBlogReaction(c *gin.Context) {
+	unreact(c, models.ContentTypeBlog)
+}
+
+// ReactToProject records a reaction to a project
+func ReactToProject(c *gin.Context) {
+	react(c, models.ContentTypeProject)
+}
+
+// RemoveProjectReaction removes the visitor's reaction of the :kind path parameter from a project
+func RemoveProjectReaction(c *gin.Context) {
+	unreact(c, models.ContentTypeProject)
+}
+
+// parseIntQuery reads an optional integer query parameter between lo and
+// hi, falling back to def. On failure it writes the error envelope and
+// returns false.
+func parseIntQuery(c *gin.Context, name string, def, lo, hi int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   name,
+			Code:    "invalid_type",
+			Message: "must be a whole number",
+		}})
+		return 0, false
+	}
+	if value < lo || value > hi {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   name,
+			Code:    "out_of_range",
+			Message: fmt.Sprintf("must be between %d and %d", lo, hi),
+		}})
+		return 0, false
+	}
+	return value, true
+}
+
+// popularContent returns the most viewed blogs or projects over the days
+// and limit query parameters, most viewed first. Deleted content is skipped.
+func popularContent(c *gin.Context, contentType string, model any) ([]uuid.UUID, map[uuid.UUID]int64, bool) {
+	days, ok := parseIntQuery(c, "days", 30, 1, 365)
+	if !ok {
+		return nil, nil, false
+	}
+	limit, ok := parseIntQuery(c, "limit", 10, 1, 50)
+	if !ok {
+		return nil, nil, false
+	}
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+
+	var rows []struct {
+		ContentID uuid.UUID
+		Views     int64
+	}
+	if err := database.DB.Model(&models.ContentView{}).
+		Select("content_id, SUM(views) AS views").
+		Where("content_type = ? AND day >= ?", contentType, since).
+		Where("content_id IN (?)", database.DB.Model(model).Select("id")).
+		Group("content_id").
+		Order("views DESC, content_id").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		apierror.Internal(c, "Error fetching popular content")
+		return nil, nil, false
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	views := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ContentID)
+		views[row.ContentID] = row.Views
+	}
+	return ids, views, true
+}
+
+// GetPopularBlogs returns the most viewed blogs. days (default 30) sets the
+// period and limit (default 10) the number of blogs.
+func GetPopularBlogs(c *gin.Context) {
+	ids, views, ok := popularContent(c, models.ContentTypeBlog, &models.Blog{})
+	if !ok {
+		return
+	}
+
+	var blogs []models.Blog
+	if err := database.DB.Scopes(withAuthors).Where("id IN ?", ids).Find(&blogs).Error; err != nil {
+		apierror.Internal(c, "Error fetching blogs")
+		return
+	}
+	byID := make(map[uuid.UUID]models.Blog, len(blogs))
+	for _, blog := range blogs {
+		byID[blog.ID] = blog
+	}
+
+	popular := make([]PopularBlog, 0, len(ids))
+	for _, id := range ids {
+		if blog, ok := byID[id]; ok {
+			popular = append(popular, PopularBlog{Blog: blog, Views: views[id]})
+		}
+	}
+
+	respondWithETag(c, http.StatusOK, popular)
+}
+
+// GetPopularProjects returns the most viewed projects. days (default 30) sets
+// the period and limit (default 10) the number of projects.
+func GetPopularProjects(c *gin.Context) {
+	ids, views, ok := popularContent(c, models.ContentTypeProject, &models.Project{})
+	if !ok {
+		return
+	}
+
+	var projects []models.Project
+	if err := database.DB.Where("id IN ?", ids).Find(&projects).Error; err != nil {
+		apierror.Internal(c, "Error fetching projects")
+		return
+	}
+	byID := make(map[uuid.UUID]models.Project, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = project
+	}
+
+	popular := make([]PopularProject, 0, len(ids))
+	for _, id := range ids {
+		if project, ok := byID[id]; ok {
+			popular = append(popular, PopularProject{Project: project, Views: views[id]})
+		}
+	}
+
+	respondWithETag(c, http.StatusOK, popular)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"avions-club/backend/apierror"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errVersionConflict is returned when a row changed between reading and writing it
+var errVersionConflict = errors.New("resource was modified concurrently")
+
+// entityTag returns a strong ETag computed from the JSON representation of v
+func entityTag(v any) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return bodyTag(body), nil
+}
+
+// matchesTag reports whether an If-Match or If-None-Match header value lists
+// tag. Weak validators are compared by their opaque value.
+func matchesTag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyTag returns a strong ETag for a response body
+func bodyTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// respondWithETag writes v as JSON along with its ETag. Safe requests whose
+// If-None-Match header already lists the tag get 304 Not Modified instead.
+func respondWithETag(c *gin.Context, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		apierror.Internal(c, "Error encoding response")
+		return
+	}
+
+	respondBodyWithETag(c, status, "application/json; charset=utf-8", body)
+}
+
+// respondBodyWithETag writes an already encoded body along with its ETag,
+// answering 304 Not Modified when If-None-Match already lists the tag
+func respondBodyWithETag(c *gin.Context, status int, contentType string, body []byte) {
+	tag := bodyTag(body)
+	c.Header("ETag", tag)
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		if inm := c.GetHeader("If-None-Match"); inm != "" && matchesTag(inm, tag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.Data(status, contentType, body)
+}
+
+// checkIfMatch requires an If-Match header matching the current
+// representation of a resource before it is modified. On failure it writes
+// the error envelope and returns false.
+func checkIfMatch(c *gin.Context, current any) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		apierror.Respond(c, http.StatusPreconditionRequired, apierror.CodePreconditionRequired,
+			"If-Match header is required; fetch the resource to obtain its ETag")
+		return false
+	}
+
+	tag, err := entityTag(current)
+	if err != nil {
+		apierror.Internal(c, "Error encoding resource")
+		return false
+	}
+	if !matchesTag(ifMatch, tag) {
+		c.Header("ETag", tag)
+		apierror.Respond(c, http.StatusPreconditionFailed, apierror.CodePreconditionFailed,
+			"Resource has been modified; fetch it again and retry")
+		return false
+	}
+	return true
+}
+
+// saveVersioned writes every column of value only if its version column still
+// holds the value it had when the row was read, then bumps the version
+func saveVersioned(tx *gorm.DB, value any, version *int) error {
+	expected := *version
+	*version = expected + 1
+	result := tx.Select("*").Omit(clause.Associations).
+		Where("version = ?", expected).
+		Save(value)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = errVersionConflict
+	}
+	if result.Error != nil {
+		*version = expected
+	}
+	return result.Error
+}
+
+// deleteVersioned deletes value only if its version column is unchanged
+func deleteVersioned(tx *gorm.DB, value any, version int) error {
+	result := tx.Where("version = ?", version).Delete(value)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return result.Error
+}
+
+// respondWriteError maps errors from versioned writes to the error envelope
+func respondWriteError(c *gin.Context, err error, message string) {
+	if errors.Is(err, errVersionConflict) {
+		apierror.Respond(c, http.StatusPreconditionFailed, apierror.CodePreconditionFailed,
+			"Resource has been modified; fetch it again and retry")
+		return
+	}
+	apierror.Internal(c, message)
+}
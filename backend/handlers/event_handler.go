@@ -0,0 +1,595 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/events"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// errEventOver is returned when replying to an event that has ended
+	errEventOver = errors.New("event has already ended")
+	// errEventCancelled is returned when replying to a cancelled event
+	errEventCancelled = errors.New("event has been cancelled")
+	// errAlreadyReplied is returned when an email already has an active RSVP
+	errAlreadyReplied = errors.New("this email has already replied to the event")
+)
+
+// EventRequest is the body accepted when creating or replacing an event
+type EventRequest struct {
+	Title       string      `json:"title" binding:"required,max=255"`
+	Description string      `json:"description" binding:"max=10000"`
+	Kind        string      `json:"kind" binding:"required,oneof=flight_test workshop competition meeting other"`
+	Location    string      `json:"location" binding:"max=255"`
+	Status      string      `json:"status" binding:"omitempty,oneof=scheduled cancelled"`
+	Tags        []string    `json:"tags" binding:"omitempty,max=20,dive,required,max=50"`
+	ImageURL    string      `json:"imageUrl" binding:"omitempty,max=2048,storageurl=images"`
+	StartsAt    time.Time   `json:"startsAt" binding:"required"`
+	EndsAt      time.Time   `json:"endsAt" binding:"required,gtfield=StartsAt"`
+	Capacity    *int        `json:"capacity" binding:"omitempty,min=1"`
+	ProjectIDs  []uuid.UUID `json:"projectIds" binding:"omitempty,max=20,dive,required"`
+}
+
+// newEventRequest returns the request that would recreate an event as it is
+func newEventRequest(event *models.Event) EventRequest {
+	req := EventRequest{
+		Title:       event.Title,
+		Description: event.Description,
+		Kind:        event.Kind,
+		Location:    event.Location,
+		Status:      event.Status,
+		Tags:        event.Tags,
+		ImageURL:    event.ImageURL,
+		StartsAt:    event.StartsAt,
+		EndsAt:      event.EndsAt,
+		Capacity:    event.Capacity,
+	}
+	for _, project := range event.Projects {
+		req.ProjectIDs = append(req.ProjectIDs, project.ID)
+	}
+	return req
+}
+
+// apply copies the request onto an event
+func (r *EventRequest) apply(event *models.Event) {
+	event.Title = r.Title
+	event.Description = r.Description
+	event.Kind = r.Kind
+	event.Location = r.Location
+	event.Status = r.Status
+	if event.Status == "" {
+		event.Status = models.EventStatusScheduled
+	}
+	event.Tags = normalizeTags(r.Tags)
+	event.ImageURL = r.ImageURL
+	event.StartsAt = r.StartsAt
+	event.EndsAt = r.EndsAt
+	event.Capacity = r.Capacity
+}
+
+// checkEventRequest verifies the linked projects of a decoded event request.
+// On failure it writes the error envelope and returns false.
+func checkEventRequest(c *gin.Context, req *EventRequest) bool {
+	exists, err := existingIDs(&models.Project{}, req.ProjectIDs)
+	if err != nil {
+		apierror.Internal(c, "Error checking event projects")
+		return false
+	}
+	if fields := missingIDFields("projectIds", req.ProjectIDs, exists, "must reference an existing project"); len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// RSVPRequest is the body of a public reply to an event. Website is a
+// honeypot, see honeypotTripped.
+type RSVPRequest struct {
+	Name     string     `json:"name" binding:"required,max=255"`
+	Email    string     `json:"email" binding:"required,email,max=255"`
+	MemberID *uuid.UUID `json:"memberId"`
+	Website  string     `json:"website"`
+}
+
+// RSVPResponse is returned once when replying to an event. CancelToken is
+// needed to cancel the RSVP and is not stored in readable form.
+type RSVPResponse struct {
+	RSVP        models.EventRSVP `json:"rsvp"`
+	CancelToken string           `json:"cancelToken"`
+}
+
+// withEventDetails preloads the projects linked to an event
+func withEventDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Projects")
+}
+
+// countRSVPs fills in how many people are going to and waitlisted for events
+func countRSVPs(events []models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	var counts []struct {
+		EventID uuid.UUID
+		Status  string
+		Count   int64
+	}
+	if err := database.DB.Model(&models.EventRSVP{}).
+		Select("event_id, status, COUNT(*) AS count").
+		Where("event_id IN ? AND status IN ?", ids,
+			[]string{models.RSVPStatusGoing, models.RSVPStatusWaitlisted}).
+		Group("event_id, status").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	index := make(map[uuid.UUID]*models.Event, len(events))
+	for i := range events {
+		index[events[i].ID] = &events[i]
+	}
+	for _, count := range counts {
+		if event, ok := index[count.EventID]; ok {
+			if count.Status == models.RSVPStatusGoing {
+				event.Going = count.Count
+			} else {
+				event.Waitlisted = count.Count
+			}
+		}
+	}
+	return nil
+}
+
+// promoteWaitlist moves waitlisted RSVPs up, oldest first, while the event
+// has free spots
+func promoteWaitlist(tx *gorm.DB, event *models.Event) error {
+	query := tx.Model(&models.EventRSVP{}).
+		Where("event_id = ? AND status = ?", event.ID, models.RSVPStatusWaitlisted)
+
+	if event.Capacity != nil {
+		var going int64
+		if err := tx.Model(&models.EventRSVP{}).
+			Where("event_id = ? AND status = ?", event.ID, models.RSVPStatusGoing).
+			Count(&going).Error; err != nil {
+			return err
+		}
+		free := *event.Capacity - int(going)
+		if free <= 0 {
+			return nil
+		}
+		query = tx.Model(&models.EventRSVP{}).
+			Where("id IN (?)", tx.Model(&models.EventRSVP{}).
+				Select("id").
+				Where("event_id = ? AND status = ?", event.ID, models.RSVPStatusWaitlisted).
+				Order("created_at").
+				Limit(free))
+	}
+
+	return query.Update("status", models.RSVPStatusGoing).Error
+}
+
+// findEvent loads an event with its projects and RSVP counts by the :id path
+// parameter. On failure it writes the error envelope and returns false.
+func findEvent(c *gin.Context, event *models.Event) bool {
+	id, ok := parseID(c, "event")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withEventDetails).First(event, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Event not found with ID: %s", id))
+		return false
+	}
+
+	events := []models.Event{*event}
+	if err := countRSVPs(events); err != nil {
+		apierror.Internal(c, "Error counting RSVPs")
+		return false
+	}
+	*event = events[0]
+	return true
+}
+
+// respondWithEvent reloads an event after a write so the response and its
+// ETag match what a subsequent GET returns
+func respondWithEvent(c *gin.Context, status int, id uuid.UUID) {
+	var event models.Event
+	if err := database.DB.Scopes(withEventDetails).First(&event, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching event")
+		return
+	}
+
+	events := []models.Event{event}
+	if err := countRSVPs(events); err != nil {
+		apierror.Internal(c, "Error counting RSVPs")
+		return
+	}
+
+	respondWithETag(c, status, events[0])
+}
+
+// GetEvents returns upcoming events, soonest first. The when query parameter
+// selects "past" events (latest first) or "all"; kind and projectId filter.
+func GetEvents(c *gin.Context) {
+	db := database.DB.Scopes(withEventDetails)
+	now := time.Now()
+	switch c.DefaultQuery("when", "upcoming") {
+	case "upcoming":
+		db = db.Where("ends_at >= ?", now).Order("starts_at")
+	case "past":
+		db = db.Where("ends_at < ?", now).Order("starts_at DESC")
+	case "all":
+		db = db.Order("starts_at DESC")
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "when",
+			Code:    "invalid_choice",
+			Message: "must be one of: upcoming, past, all",
+		}})
+		return
+	}
+
+	if kind := c.Query("kind"); kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+	if tag := c.Query("tag"); tag != "" {
+		tags, _ := json.Marshal(normalizeTags([]string{tag}))
+		db = db.Where("tags @> ?::jsonb", string(tags))
+	}
+	if projectID := c.Query("projectId"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid project ID format: %s", projectID))
+			return
+		}
+		db = db.Where("id IN (?)", database.DB.Table("event_projects").
+			Select("event_id").
+			Where("project_id = ?", id))
+	}
+
+	var events []models.Event
+	if err := db.Find(&events).Error; err != nil {
+		apierror.Internal(c, "Error fetching events")
+		return
+	}
+	if err := countRSVPs(events); err != nil {
+		apierror.Internal(c, "Error counting RSVPs")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, events)
+}
+
+// GetEvent returns a specific event
+func GetEvent(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, event)
+}
+
+// saveEventProjects replaces the projects linked to an event
+func saveEventProjects(tx *gorm.DB, event *models.Event, projectIDs []uuid.UUID) error {
+	projects := make([]models.Project, 0, len(projectIDs))
+	for _, id := range projectIDs {
+		projects = append(projects, models.Project{ID: id})
+	}
+	return tx.Model(event).Omit("Projects.*").Association("Projects").Replace(projects)
+}
+
+// CreateEvent creates a new event
+func CreateEvent(c *gin.Context) {
+	var req EventRequest
+	if !bindJSON(c, &req) || !checkEventRequest(c, &req) {
+		return
+	}
+
+	event := models.Event{ID: uuid.New()}
+	req.apply(&event)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&event).Error; err != nil {
+			return err
+		}
+		if err := saveEventProjects(tx, &event, req.ProjectIDs); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.EventCreated, events.NewEventData(&event))
+	})
+	if err != nil {
+		apierror.Internal(c, "Error creating event")
+		return
+	}
+
+	respondWithEvent(c, http.StatusCreated, event.ID)
+}
+
+// saveEvent applies a validated request to an event and writes the result.
+// Raising the capacity lets waitlisted people in.
+func saveEvent(c *gin.Context, event *models.Event, req *EventRequest) {
+	req.apply(event)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, event, &event.Version); err != nil {
+			return err
+		}
+		if err := saveEventProjects(tx, event, req.ProjectIDs); err != nil {
+			return err
+		}
+		if err := promoteWaitlist(tx, event); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.EventUpdated, events.NewEventData(event))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error updating event")
+		return
+	}
+
+	respondWithEvent(c, http.StatusOK, event.ID)
+}
+
+// UpdateEvent replaces an existing event
+func UpdateEvent(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) || !checkIfMatch(c, event) {
+		return
+	}
+
+	var req EventRequest
+	if !bindJSON(c, &req) || !checkEventRequest(c, &req) {
+		return
+	}
+
+	saveEvent(c, &event, &req)
+}
+
+// PatchEvent partially updates an event using JSON Merge Patch
+func PatchEvent(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) || !checkIfMatch(c, event) {
+		return
+	}
+
+	req := newEventRequest(&event)
+	if !bindMergePatch(c, &req) || !checkEventRequest(c, &req) {
+		return
+	}
+
+	saveEvent(c, &event, &req)
+}
+
+// DeleteEvent deletes an event
+func DeleteEvent(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) || !checkIfMatch(c, event) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteVersioned(tx, &event, event.Version); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.EventDeleted, events.NewEventData(&event))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error deleting event")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
+}
+
+// CreateRSVP replies to an event. People get a spot while the event has
+// capacity left and join the waitlist after that.
+func CreateRSVP(c *gin.Context) {
+	eventID, ok := parseID(c, "event")
+	if !ok {
+		return
+	}
+
+	var req RSVPRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if honeypotTripped(c, req.Website, http.StatusCreated, RSVPResponse{RSVP: models.EventRSVP{
+		EventID: eventID,
+		Name:    req.Name,
+		Status:  models.RSVPStatusGoing,
+	}}) {
+		return
+	}
+	if req.MemberID != nil {
+		exists, err := existingIDs(&models.Member{}, []uuid.UUID{*req.MemberID})
+		if err != nil {
+			apierror.Internal(c, "Error checking member")
+			return
+		}
+		if !exists[*req.MemberID] {
+			apierror.Validation(c, []apierror.FieldError{{
+				Field:   "memberId",
+				Code:    "not_found",
+				Message: "must reference an existing member",
+			}})
+			return
+		}
+	}
+
+	token, hash, err := newSecretToken()
+	if err != nil {
+		apierror.Internal(c, "Error creating RSVP")
+		return
+	}
+
+	var rsvp models.EventRSVP
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the event so concurrent replies cannot overfill it
+		var event models.Event
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&event, "id = ?", eventID).Error; err != nil {
+			return err
+		}
+		if event.EndsAt.Before(time.Now()) {
+			return errEventOver
+		}
+		if event.Status == models.EventStatusCancelled {
+			return errEventCancelled
+		}
+
+		email := strings.ToLower(strings.TrimSpace(req.Email))
+		err := tx.Where("event_id = ? AND email = ?", event.ID, email).First(&rsvp).Error
+		if err == nil && rsvp.Status != models.RSVPStatusCancelled {
+			return errAlreadyReplied
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		status := models.RSVPStatusGoing
+		if event.Capacity != nil {
+			var going int64
+			if err := tx.Model(&models.EventRSVP{}).
+				Where("event_id = ? AND status = ?", event.ID, models.RSVPStatusGoing).
+				Count(&going).Error; err != nil {
+				return err
+			}
+			if int(going) >= *event.Capacity {
+				status = models.RSVPStatusWaitlisted
+			}
+		}
+
+		// A cancelled reply is reused so the email stays unique per event
+		rsvp.EventID = event.ID
+		rsvp.Name = strings.TrimSpace(req.Name)
+		rsvp.Email = email
+		rsvp.MemberID = req.MemberID
+		rsvp.Status = status
+		rsvp.TokenHash = hash
+		rsvp.CheckedInAt = nil
+		rsvp.CreatedAt = time.Now()
+		return tx.Save(&rsvp).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.NotFound(c, fmt.Sprintf("Event not found with ID: %s", eventID))
+		return
+	case errors.Is(err, errEventOver), errors.Is(err, errEventCancelled),
+		errors.Is(err, errAlreadyReplied):
+		apierror.Conflict(c, err.Error())
+		return
+	case err != nil:
+		apierror.Internal(c, "Error creating RSVP")
+		return
+	}
+
+	c.JSON(http.StatusCreated, RSVPResponse{RSVP: rsvp, CancelToken: token})
+}
+
+// CancelRSVP cancels a reply using the token returned when it was made and
+// gives the freed spot to the waitlist
+func CancelRSVP(c *gin.Context) {
+	eventID, ok := parseID(c, "event")
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&event, "id = ?", eventID).Error; err != nil {
+			return err
+		}
+
+		var rsvp models.EventRSVP
+		if err := tx.Where("event_id = ? AND token_hash = ? AND status <> ?",
+			event.ID, hashToken(c.Param("token")), models.RSVPStatusCancelled).
+			First(&rsvp).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&rsvp).Update("status", models.RSVPStatusCancelled).Error; err != nil {
+			return err
+		}
+		return promoteWaitlist(tx, &event)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.NotFound(c, "RSVP not found")
+		return
+	}
+	if err != nil {
+		apierror.Internal(c, "Error cancelling RSVP")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "RSVP cancelled successfully"})
+}
+
+// GetEventRSVPs returns every reply to an event, spots first, then the
+// waitlist in order
+func GetEventRSVPs(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) {
+		return
+	}
+
+	var rsvps []models.EventRSVP
+	if err := database.DB.Where("event_id = ?", event.ID).
+		Order(clause.Expr{SQL: "CASE status WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END",
+			Vars: []any{models.RSVPStatusGoing, models.RSVPStatusWaitlisted}}).
+		Order("created_at").
+		Find(&rsvps).Error; err != nil {
+		apierror.Internal(c, "Error fetching RSVPs")
+		return
+	}
+
+	c.JSON(http.StatusOK, rsvps)
+}
+
+// CheckInRSVP records that someone who replied showed up to the event
+func CheckInRSVP(c *gin.Context) {
+	var event models.Event
+	if !findEvent(c, &event) {
+		return
+	}
+
+	rsvpID, err := uuid.Parse(c.Param("rsvpId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid RSVP ID format: %s", c.Param("rsvpId")))
+		return
+	}
+
+	var rsvp models.EventRSVP
+	if err := database.DB.Where("event_id = ? AND status <> ?", event.ID, models.RSVPStatusCancelled).
+		First(&rsvp, "id = ?", rsvpID).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("RSVP not found with ID: %s", rsvpID))
+		return
+	}
+
+	if rsvp.CheckedInAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&rsvp).Update("checked_in_at", now).Error; err != nil {
+			apierror.Internal(c, "Error checking in")
+			return
+		}
+		rsvp.CheckedInAt = &now
+	}
+
+	c.JSON(http.StatusOK, rsvp)
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/feed"
+	"avions-club/backend/models"
+	"avions-club/backend/newsletter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"gorm.io/gorm"
+)
+
+// feedLimit is how many of the newest blogs a feed lists
+const feedLimit = 20
+
+// maxFeedMarkdown is the largest markdown file rendered into a feed
+const maxFeedMarkdown = 1 << 20
+
+// feedMarkdown renders blog markdown to HTML. Raw HTML in the markdown is
+// left out, as on the website.
+var feedMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// feedDeadline bounds the time a feed spends fetching uncached files from
+// storage; files not fetched by then are left out of that response
+const feedDeadline = 5 * time.Second
+
+// feedFetchers is how many files a feed fetches from storage at once
+const feedFetchers = 8
+
+// maxFeedFiles is how many files the feed cache holds before it drops the
+// oldest. It fits the markdown and cover of every blog in a few dozen feeds.
+const maxFeedFiles = 1000
+
+// feedClient fetches blog markdown and cover sizes from storage
+var feedClient = &http.Client{Timeout: feedDeadline}
+
+// feedFile is what a feed needs from a stored file: the rendered HTML of a
+// markdown file, or the size of an image
+type feedFile struct {
+	html   string
+	length int64
+}
+
+// feedFiles caches feedFile by URL. Uploads get a fresh name, so a stored
+// file never changes; the oldest entries are dropped to bound its size.
+var feedFiles = struct {
+	sync.Mutex
+	byURL map[string]feedFile
+	order []string
+}{byURL: map[string]feedFile{}}
+
+// fetchFeedFile returns the cached feedFile of a URL, calling fetch on a
+// miss. Failures are not cached, so they are retried on the next request.
+func fetchFeedFile(ctx context.Context, url string, fetch func(context.Context, string) (feedFile, error)) (feedFile, error) {
+	feedFiles.Lock()
+	file, ok := feedFiles.byURL[url]
+	feedFiles.Unlock()
+	if ok {
+		return file, nil
+	}
+
+	file, err := fetch(ctx, url)
+	if err != nil {
+		return feedFile{}, err
+	}
+	feedFiles.Lock()
+	if _, ok := feedFiles.byURL[url]; !ok {
+		if len(feedFiles.order) >= maxFeedFiles {
+			delete(feedFiles.byURL, feedFiles.order[0])
+			feedFiles.order = feedFiles.order[1:]
+		}
+		feedFiles.order = append(feedFiles.order, url)
+	}
+	feedFiles.byURL[url] = file
+	feedFiles.Unlock()
+	return file, nil
+}
+
+// renderMarkdown downloads a markdown file and renders it to HTML
+func renderMarkdown(ctx context.Context, url string) (feedFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return feedFile{}, err
+	}
+	resp, err := feedClient.Do(req)
+	if err != nil {
+		return feedFile{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return feedFile{}, fmt.Errorf("storage returned %s", resp.Status)
+	}
+	markdown, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedMarkdown))
+	if err != nil {
+		return feedFile{}, err
+	}
+
+	var html bytes.Buffer
+	if err := feedMarkdown.Convert(markdown, &html); err != nil {
+		return feedFile{}, err
+	}
+	return feedFile{html: html.String()}, nil
+}
+
+// imageLength asks storage for the size of an image
+func imageLength(ctx context.Context, url string) (feedFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return feedFile{}, err
+	}
+	resp, err := feedClient.Do(req)
+	if err != nil {
+		return feedFile{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return feedFile{}, fmt.Errorf("storage returned %s", resp.Status)
+	}
+	return feedFile{length: max(resp.ContentLength, 0)}, nil
+}
+
+// toFeedItem converts a blog with its authors to a feed item. Content and
+// the cover size come from storage; when it cannot be reached before ctx is
+// done the item goes out with its description only and an unknown cover
+// size, and complete is false. fetchers limits how many files are fetched at
+// once.
+func toFeedItem(ctx context.Context, fetchers chan struct{}, blog *models.Blog) (item feed.Item, complete bool) {
+	complete = true
+	item = feed.Item{
+		ID:          "urn:uuid:" + blog.ID.String(),
+		Title:       blog.Title,
+		Link:        newsletter.SiteLink("/blogs/" + blog.ID.String()),
+		Description: blog.Description,
+		Categories:  blog.Tags,
+		Published:   blog.CreatedAt,
+		Updated:     blog.UpdatedAt,
+	}
+	for _, author := range blog.Authors {
+		item.Authors = append(item.Authors, feed.Person{
+			Name: author.Member.Name,
+			URI:  newsletter.SiteLink("/members/" + author.MemberID.String()),
+		})
+	}
+	fetch := func(url string, fetch func(context.Context, string) (feedFile, error)) (feedFile, error) {
+		select {
+		case fetchers <- struct{}{}:
+			defer func() { <-fetchers }()
+		case <-ctx.Done():
+			return feedFile{}, ctx.Err()
+		}
+		return fetchFeedFile(ctx, url, fetch)
+	}
+	if blog.MarkdownURL != "" {
+		content, err := fetch(blog.MarkdownURL, renderMarkdown)
+		if err != nil {
+			log.Printf("Error rendering blog %s for the feed: %v", blog.ID, err)
+			complete = false
+		}
+		item.Content = content.html
+	}
+	if blog.CoverURL != "" {
+		cover, err := fetch(blog.CoverURL, imageLength)
+		if err != nil {
+			log.Printf("Error reading the cover of blog %s for the feed: %v", blog.ID, err)
+			complete = false
+		}
+		contentType := mime.TypeByExtension(path.Ext(blog.CoverURL))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		item.Enclosure = &feed.Enclosure{URL: blog.CoverURL, Type: contentType, Length: cover.length}
+	}
+	return item, complete
+}
+
+// feedURL returns the public URL of the requested feed, for its ID and self
+// link. It is built from API_URL, or SITE_URL when the API is served from the
+// website, never from request headers, so the feed ID stays the same however
+// the request reached the server.
+func feedURL(c *gin.Context) string {
+	base := os.Getenv("API_URL")
+	if base == "" {
+		base = os.Getenv("SITE_URL")
+	}
+	return strings.TrimRight(base, "/") + c.Request.URL.EscapedPath()
+}
+
+// feedFormat splits the .rss or .atom extension off the last part of a feed
+// URL. Without an extension the feed is RSS.
+func feedFormat(name string) (base string, atom bool) {
+	if base, ok := strings.CutSuffix(name, ".atom"); ok {
+		return base, true
+	}
+	return strings.TrimSuffix(name, ".rss"), false
+}
+
+// respondWithFeed renders the newest blogs matching scope as an RSS or Atom
+// feed. Besides the ETag, the feed carries Last-Modified: the latest change
+// to a listed blog or its authors, or removal of a blog from the feed.
+// Neither says anything about files storage failed to return, so a feed
+// missing any of them goes out without validators and with
+// Cache-Control: no-cache.
+func respondWithFeed(c *gin.Context, atom bool, title, link string, scope func(*gorm.DB) *gorm.DB) {
+	var blogs []models.Blog
+	if err := database.DB.Scopes(withAuthors, scope).
+		Order("created_at DESC").
+		Limit(feedLimit).
+		Find(&blogs).Error; err != nil {
+		apierror.Internal(c, "Error fetching blogs")
+		return
+	}
+	var lastDeleted sql.NullTime
+	if err := database.DB.Unscoped().Model(&models.Blog{}).
+		Scopes(scope).
+		Select("MAX(deleted_at)").
+		Row().Scan(&lastDeleted); err != nil {
+		apierror.Internal(c, "Error fetching blogs")
+		return
+	}
+
+	doc := feed.Feed{
+		ID:          feedURL(c),
+		Title:       title,
+		Link:        link,
+		Self:        feedURL(c),
+		Description: "Latest posts from the Avions Club blog",
+		Items:       make([]feed.Item, len(blogs)),
+	}
+	if lastDeleted.Valid {
+		doc.Updated = lastDeleted.Time
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), feedDeadline)
+	defer cancel()
+	fetchers := make(chan struct{}, feedFetchers)
+	var wg sync.WaitGroup
+	var incomplete atomic.Bool
+	for i := range blogs {
+		blog := &blogs[i]
+		doc.Updated = latest(doc.Updated, blog.UpdatedAt)
+		for _, author := range blog.Authors {
+			doc.Updated = latest(doc.Updated, author.Member.UpdatedAt)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, complete := toFeedItem(ctx, fetchers, blog)
+			doc.Items[i] = item
+			if !complete {
+				incomplete.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	// HTTP dates have whole seconds
+	doc.Updated = doc.Updated.UTC().Truncate(time.Second)
+
+	contentType := feed.RSSContentType
+	render := doc.RSS
+	if atom {
+		contentType = feed.AtomContentType
+		render = doc.Atom
+	}
+	body, err := render()
+	if err != nil {
+		apierror.Internal(c, "Error rendering feed")
+		return
+	}
+
+	if incomplete.Load() {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, contentType, body)
+		return
+	}
+	if !doc.Updated.IsZero() {
+		c.Header("Last-Modified", doc.Updated.Format(http.TimeFormat))
+		// If-None-Match takes precedence, as in respondBodyWithETag
+		if c.GetHeader("If-None-Match") == "" {
+			since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+			if err == nil && !doc.Updated.After(since) {
+				c.Header("ETag", bodyTag(body))
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
+	}
+	respondBodyWithETag(c, http.StatusOK, contentType, body)
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// GetBlogFeed returns the feed of the newest blogs, as RSS from feed.rss
+// and as Atom from feed.atom
+func GetBlogFeed(c *gin.Context) {
+	_, atom := feedFormat(c.Request.URL.Path)
+	respondWithFeed(c, atom, "Avions Club Blog", newsletter.SiteLink("/blogs"),
+		func(db *gorm.DB) *gorm.DB { return db })
+}
+
+// GetTagFeed returns the feed of the newest blogs with a tag. The tag ends
+// in .rss or .atom to pick the format.
+func GetTagFeed(c *gin.Context) {
+	tag, atom := feedFormat(c.Param("tag"))
+	tags, _ := json.Marshal(normalizeTags([]string{tag}))
+	if string(tags) == "[]" {
+		apierror.BadRequest(c, "Tag is required")
+		return
+	}
+
+	respondWithFeed(c, atom, fmt.Sprintf("Avions Club Blog - %s", tag), newsletter.SiteLink("/blogs"),
+		func(db *gorm.DB) *gorm.DB { return db.Where("tags @> ?::jsonb", string(tags)) })
+}
+
+// GetAuthorFeed returns the feed of the newest blogs a member wrote or
+// co-authored. The member ID ends in .rss or .atom to pick the format.
+func GetAuthorFeed(c *gin.Context) {
+	rawID, atom := feedFormat(c.Param("id"))
+	memberID, err := uuid.Parse(rawID)
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid member ID format: %s", rawID))
+		return
+	}
+
+	var member models.Member
+	if err := database.DB.First(&member, "id = ?", memberID).Error; err != nil {
+		apierror.NotFound(c, "Member not found")
+		return
+	}
+
+	respondWithFeed(c, atom, fmt.Sprintf("Avions Club Blog - %s", member.Name),
+		newsletter.SiteLink("/members/"+member.ID.String()),
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("id IN (?)", database.DB.Model(&models.BlogAuthor{}).
+				Select("blog_id").
+				Where("member_id = ?", memberID))
+		})
+}
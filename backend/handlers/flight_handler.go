@@ -0,0 +1,668 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/flightcsv"
+	"avions-club/backend/models"
+	"avions-club/backend/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxImportRows caps how many flights a single CSV import may hold
+const maxImportRows = 1000
+
+// FlightLogRequest is the body accepted when creating or replacing a flight log
+type FlightLogRequest struct {
+	ProjectID       uuid.UUID `json:"projectId" binding:"required"`
+	PilotID         uuid.UUID `json:"pilotId" binding:"required"`
+	Aircraft        string    `json:"aircraft" binding:"required,max=255"`
+	FlownAt         time.Time `json:"flownAt" binding:"required"`
+	DurationSeconds int       `json:"durationSeconds" binding:"required,min=1,max=86400"`
+	Location        string    `json:"location" binding:"max=255"`
+	Conditions      string    `json:"conditions" binding:"max=2000"`
+	Outcome         string    `json:"outcome" binding:"required,oneof=success partial aborted crash"`
+	Notes           string    `json:"notes" binding:"max=10000"`
+}
+
+// newFlightLogRequest returns the request that would recreate a flight log as it is
+func newFlightLogRequest(flight *models.FlightLog) FlightLogRequest {
+	return FlightLogRequest{
+		ProjectID:       flight.ProjectID,
+		PilotID:         flight.PilotID,
+		Aircraft:        flight.Aircraft,
+		FlownAt:         flight.FlownAt,
+		DurationSeconds: flight.DurationSeconds,
+		Location:        flight.Location,
+		Conditions:      flight.Conditions,
+		Outcome:         flight.Outcome,
+		Notes:           flight.Notes,
+	}
+}
+
+// apply copies the request onto a flight log
+func (r *FlightLogRequest) apply(flight *models.FlightLog) {
+	flight.ProjectID = r.ProjectID
+	flight.PilotID = r.PilotID
+	flight.Aircraft = strings.TrimSpace(r.Aircraft)
+	flight.FlownAt = r.FlownAt
+	flight.DurationSeconds = r.DurationSeconds
+	flight.Location = r.Location
+	flight.Conditions = r.Conditions
+	flight.Outcome = r.Outcome
+	flight.Notes = r.Notes
+}
+
+// checkFlightLogRequest verifies the project and pilot of a decoded flight log
+// request. On failure it writes the error envelope and returns false.
+func checkFlightLogRequest(c *gin.Context, req *FlightLogRequest) bool {
+	projects, err := existingIDs(&models.Project{}, []uuid.UUID{req.ProjectID})
+	if err != nil {
+		apierror.Internal(c, "Error checking flight project")
+		return false
+	}
+	pilots, err := existingIDs(&models.Member{}, []uuid.UUID{req.PilotID})
+	if err != nil {
+		apierror.Internal(c, "Error checking flight pilot")
+		return false
+	}
+
+	var fields []apierror.FieldError
+	if !projects[req.ProjectID] {
+		fields = append(fields, apierror.FieldError{
+			Field:   "projectId",
+			Code:    "not_found",
+			Message: "must reference an existing project",
+		})
+	}
+	if !pilots[req.PilotID] {
+		fields = append(fields, apierror.FieldError{
+			Field:   "pilotId",
+			Code:    "not_found",
+			Message: "must reference an existing member",
+		})
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// withFlightDetails preloads the project and pilot of a flight log
+func withFlightDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Project").Preload("Pilot")
+}
+
+// activeProjectFlights limits flight logs to projects that are not in the trash
+func activeProjectFlights(db *gorm.DB) *gorm.DB {
+	return db.Where("project_id IN (?)", database.DB.Model(&models.Project{}).Select("id"))
+}
+
+// findFlightLog loads a flight log by the :id path parameter. On failure it
+// writes the error envelope and returns false.
+func findFlightLog(c *gin.Context, flight *models.FlightLog) bool {
+	id, ok := parseID(c, "flight log")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withFlightDetails, activeProjectFlights).First(flight, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Flight log not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// respondWithFlightLog reloads a flight log after a write so the response and
+// its ETag match what a subsequent GET returns
+func respondWithFlightLog(c *gin.Context, status int, id uuid.UUID) {
+	var flight models.FlightLog
+	if err := database.DB.Scopes(withFlightDetails).First(&flight, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching flight log")
+		return
+	}
+
+	respondWithETag(c, status, flight)
+}
+
+// parseDateQuery reads an optional RFC 3339 timestamp or YYYY-MM-DD date from
+// the query string. On failure it writes the error envelope and returns false.
+func parseDateQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, true
+		}
+	}
+	apierror.Validation(c, []apierror.FieldError{{
+		Field:   name,
+		Code:    "invalid",
+		Message: "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp",
+	}})
+	return nil, false
+}
+
+// GetFlightLogs returns flight logs, latest first. projectId, pilotId,
+// aircraft, outcome, from and to filter the list.
+func GetFlightLogs(c *gin.Context) {
+	db := database.DB.Scopes(withFlightDetails, activeProjectFlights).Order("flown_at DESC")
+
+	for _, filter := range []struct{ param, column string }{
+		{"projectId", "project_id"},
+		{"pilotId", "pilot_id"},
+	} {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid %s format: %s", filter.param, value))
+			return
+		}
+		db = db.Where(filter.column+" = ?", id)
+	}
+	if aircraft := c.Query("aircraft"); aircraft != "" {
+		db = db.Where("LOWER(aircraft) = LOWER(?)", aircraft)
+	}
+	if outcome := c.Query("outcome"); outcome != "" {
+		db = db.Where("outcome = ?", outcome)
+	}
+
+	from, ok := parseDateQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return
+	}
+	if from != nil {
+		db = db.Where("flown_at >= ?", *from)
+	}
+	if to != nil {
+		db = db.Where("flown_at < ?", *to)
+	}
+
+	var flights []models.FlightLog
+	if err := db.Find(&flights).Error; err != nil {
+		apierror.Internal(c, "Error fetching flight logs")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, flights)
+}
+
+// GetFlightLog returns a specific flight log
+func GetFlightLog(c *gin.Context) {
+	var flight models.FlightLog
+	if !findFlightLog(c, &flight) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, flight)
+}
+
+// CreateFlightLog records a new flight
+func CreateFlightLog(c *gin.Context) {
+	var req FlightLogRequest
+	if !bindJSON(c, &req) || !checkFlightLogRequest(c, &req) {
+		return
+	}
+
+	flight := models.FlightLog{ID: uuid.New()}
+	req.apply(&flight)
+	if err := database.DB.Omit(clause.Associations).Create(&flight).Error; err != nil {
+		apierror.Internal(c, "Error creating flight log")
+		return
+	}
+
+	respondWithFlightLog(c, http.StatusCreated, flight.ID)
+}
+
+// saveFlightLog applies a validated request to a flight log and writes the result
+func saveFlightLog(c *gin.Context, flight *models.FlightLog, req *FlightLogRequest) {
+	req.apply(flight)
+	if err := saveVersioned(database.DB, flight, &flight.Version); err != nil {
+		respondWriteError(c, err, "Error updating flight log")
+		return
+	}
+
+	respondWithFlightLog(c, http.StatusOK, flight.ID)
+}
+
+// UpdateFlightLog replaces an existing flight log
+func UpdateFlightLog(c *gin.Context) {
+	var flight models.FlightLog
+	if !findFlightLog(c, &flight) || !checkIfMatch(c, flight) {
+		return
+	}
+
+	var req FlightLogRequest
+	if !bindJSON(c, &req) || !checkFlightLogRequest(c, &req) {
+		return
+	}
+
+	saveFlightLog(c, &flight, &req)
+}
+
+// PatchFlightLog partially updates a flight log using JSON Merge Patch
+func PatchFlightLog(c *gin.Context) {
+	var flight models.FlightLog
+	if !findFlightLog(c, &flight) || !checkIfMatch(c, flight) {
+		return
+	}
+
+	req := newFlightLogRequest(&flight)
+	if !bindMergePatch(c, &req) || !checkFlightLogRequest(c, &req) {
+		return
+	}
+
+	saveFlightLog(c, &flight, &req)
+}
+
+// DeleteFlightLog moves a flight log to the trash
+func DeleteFlightLog(c *gin.Context) {
+	var flight models.FlightLog
+	if !findFlightLog(c, &flight) || !checkIfMatch(c, flight) {
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &flight, flight.Version); err != nil {
+		respondWriteError(c, err, "Error deleting flight log")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Flight log deleted successfully"})
+}
+
+// AircraftStats summarises the flights of one aircraft
+type AircraftStats struct {
+	Aircraft             string `json:"aircraft"`
+	Flights              int64  `json:"flights"`
+	TotalDurationSeconds int64  `json:"totalDurationSeconds"`
+}
+
+// FlightStats summarises the flights logged for a project or by a pilot
+type FlightStats struct {
+	Flights              int64            `json:"flights"`
+	TotalDurationSeconds int64            `json:"totalDurationSeconds"`
+	LongestFlightSeconds int64            `json:"longestFlightSeconds"`
+	FirstFlownAt         *time.Time       `json:"firstFlownAt"`
+	LastFlownAt          *time.Time       `json:"lastFlownAt"`
+	Outcomes             map[string]int64 `json:"outcomes"`
+	Aircraft             []AircraftStats  `json:"aircraft"`
+}
+
+// flightStats aggregates the flight logs matching scope
+func flightStats(scope func(*gorm.DB) *gorm.DB) (FlightStats, error) {
+	flights := func() *gorm.DB {
+		return database.DB.Model(&models.FlightLog{}).Scopes(scope, activeProjectFlights)
+	}
+
+	var totals struct {
+		Flights              int64
+		TotalDurationSeconds int64
+		LongestFlightSeconds int64
+		FirstFlownAt         *time.Time
+		LastFlownAt          *time.Time
+	}
+	if err := flights().
+		Select(`COUNT(*) AS flights,
+			COALESCE(SUM(duration_seconds), 0) AS total_duration_seconds,
+			COALESCE(MAX(duration_seconds), 0) AS longest_flight_seconds,
+			MIN(flown_at) AS first_flown_at,
+			MAX(flown_at) AS last_flown_at`).
+		Scan(&totals).Error; err != nil {
+		return FlightStats{}, err
+	}
+
+	stats := FlightStats{
+		Flights:              totals.Flights,
+		TotalDurationSeconds: totals.TotalDurationSeconds,
+		LongestFlightSeconds: totals.LongestFlightSeconds,
+		FirstFlownAt:         totals.FirstFlownAt,
+		LastFlownAt:          totals.LastFlownAt,
+		Outcomes: map[string]int64{
+			models.FlightOutcomeSuccess: 0,
+			models.FlightOutcomePartial: 0,
+			models.FlightOutcomeAborted: 0,
+			models.FlightOutcomeCrash:   0,
+		},
+		Aircraft: []AircraftStats{},
+	}
+
+	var outcomes []struct {
+		Outcome string
+		Count   int64
+	}
+	if err := flights().
+		Select("outcome, COUNT(*) AS count").
+		Group("outcome").
+		Scan(&outcomes).Error; err != nil {
+		return FlightStats{}, err
+	}
+	for _, outcome := range outcomes {
+		stats.Outcomes[outcome.Outcome] = outcome.Count
+	}
+
+	if err := flights().
+		Select("aircraft, COUNT(*) AS flights, SUM(duration_seconds) AS total_duration_seconds").
+		Group("aircraft").
+		Order("flights DESC, aircraft").
+		Scan(&stats.Aircraft).Error; err != nil {
+		return FlightStats{}, err
+	}
+	return stats, nil
+}
+
+// GetProjectFlightStats returns flight statistics for a project
+func GetProjectFlightStats(c *gin.Context) {
+	id, ok := parseID(c, "project")
+	if !ok {
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, "Project not found")
+		return
+	}
+
+	stats, err := flightStats(func(db *gorm.DB) *gorm.DB {
+		return db.Where("project_id = ?", id)
+	})
+	if err != nil {
+		apierror.Internal(c, "Error computing flight statistics")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, stats)
+}
+
+// GetMemberFlightStats returns flight statistics for a pilot
+func GetMemberFlightStats(c *gin.Context) {
+	id, ok := parseID(c, "member")
+	if !ok {
+		return
+	}
+
+	var member models.Member
+	if err := database.DB.First(&member, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Member not found with ID: %s", id))
+		return
+	}
+
+	stats, err := flightStats(func(db *gorm.DB) *gorm.DB {
+		return db.Where("pilot_id = ?", id)
+	})
+	if err != nil {
+		apierror.Internal(c, "Error computing flight statistics")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, stats)
+}
+
+// FlightImportResponse reports the outcome of a CSV import
+type FlightImportResponse struct {
+	Imported int                `json:"imported"`
+	Skipped  int                `json:"skipped"`
+	Flights  []models.FlightLog `json:"flights"`
+}
+
+// resolvePilots maps the pilot cells of an import to members. A cell may hold
+// a member ID or a member's name; names must match exactly one member.
+func resolvePilots(rows []flightcsv.Row) (map[string]uuid.UUID, map[string]string, error) {
+	resolved := make(map[string]uuid.UUID)
+	problems := make(map[string]string)
+
+	var ids []uuid.UUID
+	var names []string
+	for _, row := range rows {
+		if row.Pilot == "" {
+			continue
+		}
+		if id, err := uuid.Parse(row.Pilot); err == nil {
+			ids = append(ids, id)
+		} else {
+			names = append(names, strings.ToLower(row.Pilot))
+		}
+	}
+
+	exists, err := existingIDs(&models.Member{}, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var members []models.Member
+	if len(names) > 0 {
+		if err := database.DB.Select("id", "name").
+			Where("LOWER(name) IN ?", names).
+			Find(&members).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	byName := make(map[string][]uuid.UUID)
+	for _, member := range members {
+		key := strings.ToLower(member.Name)
+		byName[key] = append(byName[key], member.ID)
+	}
+
+	for _, row := range rows {
+		if row.Pilot == "" {
+			continue
+		}
+		if id, err := uuid.Parse(row.Pilot); err == nil {
+			if exists[id] {
+				resolved[row.Pilot] = id
+			} else {
+				problems[row.Pilot] = "must reference an existing member"
+			}
+			continue
+		}
+		switch matches := byName[strings.ToLower(row.Pilot)]; len(matches) {
+		case 0:
+			problems[row.Pilot] = "must name an existing member"
+		case 1:
+			resolved[row.Pilot] = matches[0]
+		default:
+			problems[row.Pilot] = "matches more than one member; use the member ID"
+		}
+	}
+	return resolved, problems, nil
+}
+
+// ImportFlightLogs creates flight logs for a project from a CSV export of
+// ground-station software. Form fields pilotId, aircraft, location and
+// outcome fill in cells the file leaves blank. Flights already logged for the
+// project (same aircraft and start time) are skipped, so re-importing a file
+// is harmless. Nothing is imported when any row is invalid.
+func ImportFlightLogs(c *gin.Context) {
+	var fields []apierror.FieldError
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		fields = append(fields, apierror.FieldError{Field: "file", Code: "required", Message: "is required"})
+	} else if file.Size > 5<<20 {
+		fields = append(fields, apierror.FieldError{Field: "file", Code: "too_large", Message: "must be at most 5MB"})
+	}
+
+	projectID, err := uuid.Parse(c.PostForm("projectId"))
+	if err != nil {
+		fields = append(fields, apierror.FieldError{Field: "projectId", Code: "required", Message: "must be a project ID"})
+	} else if exists, err := existingIDs(&models.Project{}, []uuid.UUID{projectID}); err != nil {
+		apierror.Internal(c, "Error checking flight project")
+		return
+	} else if !exists[projectID] {
+		fields = append(fields, apierror.FieldError{Field: "projectId", Code: "not_found", Message: "must reference an existing project"})
+	}
+
+	var defaultPilot uuid.UUID
+	if value := c.PostForm("pilotId"); value != "" {
+		if defaultPilot, err = uuid.Parse(value); err != nil {
+			fields = append(fields, apierror.FieldError{Field: "pilotId", Code: "invalid", Message: "must be a member ID"})
+		} else if exists, err := existingIDs(&models.Member{}, []uuid.UUID{defaultPilot}); err != nil {
+			apierror.Internal(c, "Error checking flight pilot")
+			return
+		} else if !exists[defaultPilot] {
+			fields = append(fields, apierror.FieldError{Field: "pilotId", Code: "not_found", Message: "must reference an existing member"})
+		}
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		apierror.BadRequest(c, "Error reading uploaded file")
+		return
+	}
+	defer src.Close()
+
+	loc, _ := time.LoadLocation(calendarTimezone())
+	rows, rowErrs, err := flightcsv.Parse(src, loc, maxImportRows)
+	if err != nil {
+		message := err.Error()
+		if errors.Is(err, flightcsv.ErrTooManyRows) {
+			message = fmt.Sprintf("must hold at most %d flights", maxImportRows)
+		}
+		apierror.Validation(c, []apierror.FieldError{{Field: "file", Code: "invalid", Message: message}})
+		return
+	}
+	for _, rowErr := range rowErrs {
+		fields = append(fields, apierror.FieldError{
+			Field:   fmt.Sprintf("line[%d].%s", rowErr.Line, rowErr.Column),
+			Code:    "invalid",
+			Message: rowErr.Message,
+		})
+	}
+
+	pilots, problems, err := resolvePilots(rows)
+	if err != nil {
+		apierror.Internal(c, "Error checking flight pilots")
+		return
+	}
+
+	requests := make([]FlightLogRequest, 0, len(rows))
+	for _, row := range rows {
+		req := FlightLogRequest{
+			ProjectID:       projectID,
+			PilotID:         defaultPilot,
+			Aircraft:        firstNonEmpty(row.Aircraft, c.PostForm("aircraft")),
+			FlownAt:         row.FlownAt,
+			DurationSeconds: int(row.Duration / time.Second),
+			Location:        firstNonEmpty(row.Location, c.PostForm("location")),
+			Conditions:      row.Conditions,
+			Outcome:         firstNonEmpty(row.Outcome, c.PostForm("outcome")),
+			Notes:           row.Notes,
+		}
+		if row.Pilot != "" {
+			if problem, ok := problems[row.Pilot]; ok {
+				fields = append(fields, apierror.FieldError{
+					Field:   fmt.Sprintf("line[%d].pilotId", row.Line),
+					Code:    "not_found",
+					Message: problem,
+				})
+				continue
+			}
+			req.PilotID = pilots[row.Pilot]
+		}
+
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			rowFields, _ := validation.FieldErrors(err)
+			for _, field := range rowFields {
+				// Cells that could not be parsed are already reported
+				if (field.Field == "flownAt" && row.FlownAt.IsZero()) ||
+					(field.Field == "durationSeconds" && row.Duration == 0) {
+					continue
+				}
+				field.Field = fmt.Sprintf("line[%d].%s", row.Line, field.Field)
+				fields = append(fields, field)
+			}
+		}
+		requests = append(requests, req)
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return
+	}
+
+	var existing []models.FlightLog
+	if err := database.DB.Select("aircraft", "flown_at").
+		Where("project_id = ?", projectID).
+		Find(&existing).Error; err != nil {
+		apierror.Internal(c, "Error checking logged flights")
+		return
+	}
+	seen := make(map[string]bool, len(existing))
+	flightKey := func(aircraft string, flownAt time.Time) string {
+		return fmt.Sprintf("%s|%d", strings.ToLower(strings.TrimSpace(aircraft)), flownAt.Unix())
+	}
+	for _, flight := range existing {
+		seen[flightKey(flight.Aircraft, flight.FlownAt)] = true
+	}
+
+	response := FlightImportResponse{Flights: []models.FlightLog{}}
+	var flights []models.FlightLog
+	for i := range requests {
+		key := flightKey(requests[i].Aircraft, requests[i].FlownAt)
+		if seen[key] {
+			response.Skipped++
+			continue
+		}
+		seen[key] = true
+
+		flight := models.FlightLog{ID: uuid.New()}
+		requests[i].apply(&flight)
+		flights = append(flights, flight)
+	}
+
+	if len(flights) > 0 {
+		if err := database.DB.Omit(clause.Associations).CreateInBatches(&flights, 100).Error; err != nil {
+			apierror.Internal(c, "Error importing flight logs")
+			return
+		}
+
+		ids := make([]uuid.UUID, 0, len(flights))
+		for _, flight := range flights {
+			ids = append(ids, flight.ID)
+		}
+		if err := database.DB.Scopes(withFlightDetails).
+			Where("id IN ?", ids).
+			Order("flown_at").
+			Find(&response.Flights).Error; err != nil {
+			apierror.Internal(c, "Error fetching flight logs")
+			return
+		}
+	}
+	response.Imported = len(flights)
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// firstNonEmpty returns the first of values that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"avions-club/backend/apierror"
 	"avions-club/backend/database"
 
 	"github.com/gin-gonic/gin"
@@ -12,20 +13,14 @@ func HealthCheck(c *gin.Context) {
 	// Check database connection
 	sqlDB, err := database.DB.DB()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error":  "Database connection error",
-		})
+		apierror.Internal(c, "Database connection error")
 		return
 	}
 
 	// Ping database
 	err = sqlDB.Ping()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error":  "Database ping failed",
-		})
+		apierror.Internal(c, "Database ping failed")
 		return
 	}
 
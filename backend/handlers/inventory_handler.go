@@ -0,0 +1,659 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lowStockUsageWindow is how far back the low-stock report sums consumption
+const lowStockUsageWindow = 30 * 24 * time.Hour
+
+var (
+	// errNotEnoughStock is returned when fewer units are available than requested
+	errNotEnoughStock = errors.New("not enough units available")
+	// errItemRetired is returned when checking out a retired item
+	errItemRetired = errors.New("item is retired")
+	// errCheckoutClosed is returned when returning a checkout that is not open
+	errCheckoutClosed = errors.New("checkout is not open")
+)
+
+// InventoryItemRequest is the body accepted when creating or replacing an
+// inventory item
+type InventoryItemRequest struct {
+	Name              string `json:"name" binding:"required,max=255"`
+	Description       string `json:"description" binding:"max=5000"`
+	Category          string `json:"category" binding:"required,oneof=battery motor radio airframe electronics tool part other"`
+	Consumable        bool   `json:"consumable"`
+	Quantity          int    `json:"quantity" binding:"min=0,max=100000"`
+	LowStockThreshold *int   `json:"lowStockThreshold" binding:"omitempty,min=0,max=100000"`
+	Condition         string `json:"condition" binding:"omitempty,oneof=new good fair damaged retired"`
+	Location          string `json:"location" binding:"max=255"`
+	ImageURL          string `json:"imageUrl" binding:"omitempty,max=2048,storageurl=images"`
+}
+
+// newInventoryItemRequest returns the request that would recreate an item as it is
+func newInventoryItemRequest(item *models.InventoryItem) InventoryItemRequest {
+	return InventoryItemRequest{
+		Name:              item.Name,
+		Description:       item.Description,
+		Category:          item.Category,
+		Consumable:        item.Consumable,
+		Quantity:          item.Quantity,
+		LowStockThreshold: item.LowStockThreshold,
+		Condition:         item.Condition,
+		Location:          item.Location,
+		ImageURL:          item.ImageURL,
+	}
+}
+
+// apply copies the request onto an inventory item
+func (r *InventoryItemRequest) apply(item *models.InventoryItem) {
+	item.Name = r.Name
+	item.Description = r.Description
+	item.Category = r.Category
+	item.Consumable = r.Consumable
+	item.Quantity = r.Quantity
+	item.LowStockThreshold = r.LowStockThreshold
+	item.Condition = r.Condition
+	if item.Condition == "" {
+		item.Condition = models.InventoryConditionGood
+	}
+	item.Location = r.Location
+	item.ImageURL = r.ImageURL
+}
+
+// checkInventoryItemRequest makes sure an update keeps the units that are
+// checked out. On failure it writes the error envelope and returns false.
+func checkInventoryItemRequest(c *gin.Context, item *models.InventoryItem, req *InventoryItemRequest) bool {
+	var fields []apierror.FieldError
+	if item.CheckedOut > 0 && int64(req.Quantity) < item.CheckedOut {
+		fields = append(fields, apierror.FieldError{
+			Field:   "quantity",
+			Code:    "too_small",
+			Message: fmt.Sprintf("must be at least %d, the units checked out", item.CheckedOut),
+		})
+	}
+	if item.CheckedOut > 0 && req.Consumable != item.Consumable {
+		fields = append(fields, apierror.FieldError{
+			Field:   "consumable",
+			Code:    "not_allowed",
+			Message: "cannot change while units are checked out",
+		})
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// openCheckouts limits checkouts to units that are still out
+func openCheckouts(db *gorm.DB) *gorm.DB {
+	return db.Where("returned_at IS NULL AND consumed = ?", false)
+}
+
+// countCheckedOut fills in how many units of items are checked out and how
+// many are left to lend
+func countCheckedOut(items []*models.InventoryItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	var counts []struct {
+		ItemID   uuid.UUID
+		Quantity int64
+	}
+	if err := database.DB.Model(&models.InventoryCheckout{}).
+		Scopes(openCheckouts).
+		Select("item_id, SUM(quantity) AS quantity").
+		Where("item_id IN ?", ids).
+		Group("item_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	checkedOut := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		checkedOut[count.ItemID] = count.Quantity
+	}
+	for _, item := range items {
+		item.CheckedOut = checkedOut[item.ID]
+		item.Available = int64(item.Quantity) - item.CheckedOut
+	}
+	return nil
+}
+
+// itemPointers returns pointers to the elements of items
+func itemPointers(items []models.InventoryItem) []*models.InventoryItem {
+	pointers := make([]*models.InventoryItem, 0, len(items))
+	for i := range items {
+		pointers = append(pointers, &items[i])
+	}
+	return pointers
+}
+
+// findInventoryItem loads an item with its checkout counts by the :id path
+// parameter. On failure it writes the error envelope and returns false.
+func findInventoryItem(c *gin.Context, item *models.InventoryItem) bool {
+	id, ok := parseID(c, "item")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.First(item, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Item not found with ID: %s", id))
+		return false
+	}
+	if err := countCheckedOut([]*models.InventoryItem{item}); err != nil {
+		apierror.Internal(c, "Error counting checked out units")
+		return false
+	}
+	return true
+}
+
+// respondWithInventoryItem reloads an item after a write so the response and
+// its ETag match what a subsequent GET returns
+func respondWithInventoryItem(c *gin.Context, status int, id uuid.UUID) {
+	var item models.InventoryItem
+	if err := database.DB.First(&item, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching item")
+		return
+	}
+	if err := countCheckedOut([]*models.InventoryItem{&item}); err != nil {
+		apierror.Internal(c, "Error counting checked out units")
+		return
+	}
+
+	respondWithETag(c, status, item)
+}
+
+// GetInventory returns inventory items by name. category, consumable and q
+// (matched against the name and location) filter the list.
+func GetInventory(c *gin.Context) {
+	db := database.DB.Order("name")
+	if category := c.Query("category"); category != "" {
+		db = db.Where("category = ?", category)
+	}
+	if consumable := c.Query("consumable"); consumable != "" {
+		value, err := strconv.ParseBool(consumable)
+		if err != nil {
+			apierror.Validation(c, []apierror.FieldError{{
+				Field:   "consumable",
+				Code:    "invalid",
+				Message: "must be true or false",
+			}})
+			return
+		}
+		db = db.Where("consumable = ?", value)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := "%" + q + "%"
+		db = db.Where("name ILIKE ? OR location ILIKE ?", pattern, pattern)
+	}
+
+	var items []models.InventoryItem
+	if err := db.Find(&items).Error; err != nil {
+		apierror.Internal(c, "Error fetching inventory")
+		return
+	}
+	if err := countCheckedOut(itemPointers(items)); err != nil {
+		apierror.Internal(c, "Error counting checked out units")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, items)
+}
+
+// GetInventoryItem returns a specific inventory item
+func GetInventoryItem(c *gin.Context) {
+	var item models.InventoryItem
+	if !findInventoryItem(c, &item) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, item)
+}
+
+// CreateInventoryItem adds an item to the inventory
+func CreateInventoryItem(c *gin.Context) {
+	var req InventoryItemRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	item := models.InventoryItem{ID: uuid.New()}
+	req.apply(&item)
+	if err := database.DB.Create(&item).Error; err != nil {
+		apierror.Internal(c, "Error creating item")
+		return
+	}
+
+	respondWithInventoryItem(c, http.StatusCreated, item.ID)
+}
+
+// saveInventoryItem applies a validated request to an item and writes the result
+func saveInventoryItem(c *gin.Context, item *models.InventoryItem, req *InventoryItemRequest) {
+	if !checkInventoryItemRequest(c, item, req) {
+		return
+	}
+
+	req.apply(item)
+	if err := saveVersioned(database.DB, item, &item.Version); err != nil {
+		respondWriteError(c, err, "Error updating item")
+		return
+	}
+
+	respondWithInventoryItem(c, http.StatusOK, item.ID)
+}
+
+// UpdateInventoryItem replaces an existing inventory item
+func UpdateInventoryItem(c *gin.Context) {
+	var item models.InventoryItem
+	if !findInventoryItem(c, &item) || !checkIfMatch(c, item) {
+		return
+	}
+
+	var req InventoryItemRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	saveInventoryItem(c, &item, &req)
+}
+
+// PatchInventoryItem partially updates an inventory item using JSON Merge Patch
+func PatchInventoryItem(c *gin.Context) {
+	var item models.InventoryItem
+	if !findInventoryItem(c, &item) || !checkIfMatch(c, item) {
+		return
+	}
+
+	req := newInventoryItemRequest(&item)
+	if !bindMergePatch(c, &req) {
+		return
+	}
+
+	saveInventoryItem(c, &item, &req)
+}
+
+// DeleteInventoryItem removes an item from the inventory. Items with units
+// checked out cannot be deleted until they are returned.
+func DeleteInventoryItem(c *gin.Context) {
+	var item models.InventoryItem
+	if !findInventoryItem(c, &item) || !checkIfMatch(c, item) {
+		return
+	}
+	if item.CheckedOut > 0 {
+		apierror.ConflictWithDetails(c, "Item has units checked out",
+			gin.H{"checkedOut": item.CheckedOut})
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &item, item.Version); err != nil {
+		respondWriteError(c, err, "Error deleting item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+}
+
+// LowStockItem is a consumable at or below its low-stock threshold
+type LowStockItem struct {
+	models.InventoryItem
+	UsedLast30Days int64 `json:"usedLast30Days"`
+}
+
+// GetLowStock reports consumables at or below their low-stock threshold,
+// emptiest first, with how many units were used over the last 30 days
+func GetLowStock(c *gin.Context) {
+	var items []models.InventoryItem
+	if err := database.DB.
+		Where("consumable = ? AND low_stock_threshold IS NOT NULL AND quantity <= low_stock_threshold", true).
+		Order("quantity - low_stock_threshold, name").
+		Find(&items).Error; err != nil {
+		apierror.Internal(c, "Error fetching inventory")
+		return
+	}
+	if err := countCheckedOut(itemPointers(items)); err != nil {
+		apierror.Internal(c, "Error counting checked out units")
+		return
+	}
+
+	report := make([]LowStockItem, 0, len(items))
+	if len(items) > 0 {
+		ids := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+
+		var usage []struct {
+			ItemID   uuid.UUID
+			Quantity int64
+		}
+		if err := database.DB.Model(&models.InventoryCheckout{}).
+			Select("item_id, SUM(quantity) AS quantity").
+			Where("item_id IN ? AND consumed = ? AND checked_out_at >= ?",
+				ids, true, time.Now().Add(-lowStockUsageWindow)).
+			Group("item_id").
+			Scan(&usage).Error; err != nil {
+			apierror.Internal(c, "Error summing consumption")
+			return
+		}
+		used := make(map[uuid.UUID]int64, len(usage))
+		for _, u := range usage {
+			used[u.ItemID] = u.Quantity
+		}
+
+		for _, item := range items {
+			report = append(report, LowStockItem{InventoryItem: item, UsedLast30Days: used[item.ID]})
+		}
+	}
+
+	respondWithETag(c, http.StatusOK, report)
+}
+
+// CheckoutRequest is the body accepted when handing out units of an item
+type CheckoutRequest struct {
+	MemberID  uuid.UUID  `json:"memberId" binding:"required"`
+	ProjectID *uuid.UUID `json:"projectId"`
+	Quantity  int        `json:"quantity" binding:"required,min=1,max=100000"`
+	DueAt     *time.Time `json:"dueAt"`
+	Notes     string     `json:"notes" binding:"max=2000"`
+}
+
+// checkCheckoutRequest verifies the member, project and due date of a
+// checkout. On failure it writes the error envelope and returns false.
+func checkCheckoutRequest(c *gin.Context, item *models.InventoryItem, req *CheckoutRequest) bool {
+	members, err := existingIDs(&models.Member{}, []uuid.UUID{req.MemberID})
+	if err != nil {
+		apierror.Internal(c, "Error checking checkout member")
+		return false
+	}
+
+	var fields []apierror.FieldError
+	if !members[req.MemberID] {
+		fields = append(fields, apierror.FieldError{
+			Field:   "memberId",
+			Code:    "not_found",
+			Message: "must reference an existing member",
+		})
+	}
+	if req.ProjectID != nil {
+		projects, err := existingIDs(&models.Project{}, []uuid.UUID{*req.ProjectID})
+		if err != nil {
+			apierror.Internal(c, "Error checking checkout project")
+			return false
+		}
+		if !projects[*req.ProjectID] {
+			fields = append(fields, apierror.FieldError{
+				Field:   "projectId",
+				Code:    "not_found",
+				Message: "must reference an existing project",
+			})
+		}
+	}
+	if req.DueAt != nil && item.Consumable {
+		fields = append(fields, apierror.FieldError{
+			Field:   "dueAt",
+			Code:    "not_allowed",
+			Message: "consumables are not returned",
+		})
+	} else if req.DueAt != nil && !req.DueAt.After(time.Now()) {
+		fields = append(fields, apierror.FieldError{
+			Field:   "dueAt",
+			Code:    "out_of_range",
+			Message: "must be in the future",
+		})
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// withCheckoutDetails preloads the item, member and project of a checkout
+func withCheckoutDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Item").Preload("Member").Preload("Project")
+}
+
+// countCheckoutItems fills in the checkout counts of the items of checkouts
+func countCheckoutItems(checkouts []models.InventoryCheckout) error {
+	var items []*models.InventoryItem
+	for i := range checkouts {
+		if checkouts[i].Item != nil {
+			items = append(items, checkouts[i].Item)
+		}
+	}
+	return countCheckedOut(items)
+}
+
+// respondWithCheckout reloads a checkout with its details after a write
+func respondWithCheckout(c *gin.Context, status int, id uuid.UUID) {
+	var checkout models.InventoryCheckout
+	if err := database.DB.Scopes(withCheckoutDetails).First(&checkout, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching checkout")
+		return
+	}
+	checkouts := []models.InventoryCheckout{checkout}
+	if err := countCheckoutItems(checkouts); err != nil {
+		apierror.Internal(c, "Error counting checked out units")
+		return
+	}
+
+	c.JSON(status, checkouts[0])
+}
+
+// CheckoutInventoryItem hands units of an item to a member. Consumables are
+// taken out of stock for good; other items are lent until they are returned.
+func CheckoutInventoryItem(c *gin.Context) {
+	var item models.InventoryItem
+	if !findInventoryItem(c, &item) {
+		return
+	}
+
+	var req CheckoutRequest
+	if !bindJSON(c, &req) || !checkCheckoutRequest(c, &item, &req) {
+		return
+	}
+
+	checkout := models.InventoryCheckout{
+		ID:           uuid.New(),
+		ItemID:       item.ID,
+		MemberID:     req.MemberID,
+		ProjectID:    req.ProjectID,
+		Quantity:     req.Quantity,
+		Consumed:     item.Consumable,
+		Notes:        req.Notes,
+		CheckedOutAt: time.Now(),
+		DueAt:        req.DueAt,
+	}
+	var available int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the item so concurrent checkouts cannot hand out the same units
+		var current models.InventoryItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "id = ?", item.ID).Error; err != nil {
+			return err
+		}
+		if current.Condition == models.InventoryConditionRetired {
+			return errItemRetired
+		}
+
+		var checkedOut int64
+		if err := tx.Model(&models.InventoryCheckout{}).
+			Scopes(openCheckouts).
+			Where("item_id = ?", current.ID).
+			Select("COALESCE(SUM(quantity), 0)").
+			Scan(&checkedOut).Error; err != nil {
+			return err
+		}
+		available = int64(current.Quantity) - checkedOut
+		if int64(req.Quantity) > available {
+			return errNotEnoughStock
+		}
+
+		if current.Consumable {
+			if err := tx.Model(&current).Updates(map[string]any{
+				"quantity": gorm.Expr("quantity - ?", req.Quantity),
+				"version":  gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit(clause.Associations).Create(&checkout).Error
+	})
+	switch {
+	case errors.Is(err, errItemRetired):
+		apierror.Conflict(c, "Retired items cannot be checked out")
+		return
+	case errors.Is(err, errNotEnoughStock):
+		apierror.ConflictWithDetails(c, "Not enough units available",
+			gin.H{"available": available})
+		return
+	case err != nil:
+		apierror.Internal(c, "Error checking out item")
+		return
+	}
+
+	respondWithCheckout(c, http.StatusCreated, checkout.ID)
+}
+
+// GetCheckouts returns checkouts, latest first. status selects "open" (the
+// default), "overdue" (most overdue first), "returned" or "all"; itemId,
+// memberId and projectId filter.
+func GetCheckouts(c *gin.Context) {
+	db := database.DB.Scopes(withCheckoutDetails)
+	switch c.DefaultQuery("status", "open") {
+	case "open":
+		db = db.Scopes(openCheckouts).Order("checked_out_at DESC")
+	case "overdue":
+		db = db.Scopes(openCheckouts).Where("due_at < ?", time.Now()).Order("due_at")
+	case "returned":
+		db = db.Where("returned_at IS NOT NULL").Order("returned_at DESC")
+	case "all":
+		db = db.Order("checked_out_at DESC")
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: open, overdue, returned, all",
+		}})
+		return
+	}
+
+	for _, filter := range []struct{ param, column string }{
+		{"itemId", "item_id"},
+		{"memberId", "member_id"},
+		{"projectId", "project_id"},
+	} {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid %s format: %s", filter.param, value))
+			return
+		}
+		db = db.Where(filter.column+" = ?", id)
+	}
+
+	var checkouts []models.InventoryCheckout
+	if err := db.Find(&checkouts).Error; err != nil {
+		apierror.Internal(c, "Error fetching checkouts")
+		return
+	}
+	if err := countCheckoutItems(checkouts); err != nil {
+		apierror.Internal(c, "Error counting checked out units")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, checkouts)
+}
+
+// ReturnRequest is the optional body accepted when units come back
+type ReturnRequest struct {
+	Condition string `json:"condition" binding:"omitempty,oneof=new good fair damaged retired"`
+	Notes     string `json:"notes" binding:"max=2000"`
+}
+
+// ReturnCheckout records that the units of a checkout came back. The
+// condition they came back in is kept on the checkout and, for single-unit
+// items, becomes the condition of the item.
+func ReturnCheckout(c *gin.Context) {
+	checkoutID, err := uuid.Parse(c.Param("checkoutId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid checkout ID format: %s", c.Param("checkoutId")))
+		return
+	}
+
+	var req ReturnRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var checkout models.InventoryCheckout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&checkout, "id = ?", checkoutID).Error; err != nil {
+			return err
+		}
+		if checkout.ReturnedAt != nil || checkout.Consumed {
+			return errCheckoutClosed
+		}
+
+		notes := checkout.Notes
+		if req.Notes != "" {
+			notes = strings.TrimSpace(notes + "\n" + req.Notes)
+		}
+		if err := tx.Model(&checkout).Updates(map[string]any{
+			"returned_at":      time.Now(),
+			"return_condition": req.Condition,
+			"notes":            notes,
+		}).Error; err != nil {
+			return err
+		}
+
+		if req.Condition == "" {
+			return nil
+		}
+		return tx.Model(&models.InventoryItem{}).
+			Where("id = ? AND quantity = 1", checkout.ItemID).
+			Updates(map[string]any{
+				"condition": req.Condition,
+				"version":   gorm.Expr("version + 1"),
+			}).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.NotFound(c, fmt.Sprintf("Checkout not found with ID: %s", checkoutID))
+		return
+	case errors.Is(err, errCheckoutClosed):
+		apierror.Conflict(c, "Checkout has already been closed")
+		return
+	case err != nil:
+		apierror.Internal(c, "Error returning checkout")
+		return
+	}
+
+	respondWithCheckout(c, http.StatusOK, checkoutID)
+}
@@ -1,92 +1,708 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"avions-club/backend/apierror"
 	"avions-club/backend/database"
+	"avions-club/backend/events"
+	"avions-club/backend/middleware"
 	"avions-club/backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// GetMembers returns all members
+// MemberRequest is the body accepted when creating or replacing a member
+type MemberRequest struct {
+	Name           string           `json:"name" binding:"required,max=255"`
+	Position       string           `json:"position" binding:"required,max=255"`
+	ImageURL       string           `json:"imageUrl" binding:"omitempty,max=2048,storageurl=images"`
+	Status         string           `json:"status" binding:"omitempty,oneof=active alumni honorary"`
+	JoinedAt       *time.Time       `json:"joinedAt"`
+	LeftAt         *time.Time       `json:"leftAt"`
+	GraduationYear *int             `json:"graduationYear" binding:"omitempty,min=1950,max=2100"`
+	Bio            string           `json:"bio" binding:"max=10000"`
+	Department     string           `json:"department" binding:"max=255"`
+	YearOfStudy    *int             `json:"yearOfStudy" binding:"omitempty,min=1,max=10"`
+	Skills         []string         `json:"skills" binding:"omitempty,max=30,dive,required,max=50"`
+	Contacts       []ContactRequest `json:"contacts" binding:"omitempty,max=20,dive"`
+}
+
+// ContactRequest is one contact in a member request
+type ContactRequest struct {
+	Kind       string `json:"kind" binding:"required,oneof=email phone website github linkedin instagram twitter other"`
+	Value      string `json:"value" binding:"required,max=512"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+// newMemberRequest returns the request that would recreate a member as it is
+func newMemberRequest(member *models.Member) MemberRequest {
+	joinedAt := member.JoinedAt
+	req := MemberRequest{
+		Name:           member.Name,
+		Position:       member.Position,
+		ImageURL:       member.ImageURL,
+		Status:         member.Status,
+		JoinedAt:       &joinedAt,
+		LeftAt:         member.LeftAt,
+		GraduationYear: member.GraduationYear,
+		Bio:            member.Bio,
+		Department:     member.Department,
+		YearOfStudy:    member.YearOfStudy,
+		Skills:         member.Skills,
+	}
+	for _, contact := range member.Contacts {
+		req.Contacts = append(req.Contacts, ContactRequest{
+			Kind:       contact.Kind,
+			Value:      contact.Value,
+			Visibility: contact.Visibility,
+		})
+	}
+	return req
+}
+
+// apply copies the request onto a member
+func (r *MemberRequest) apply(member *models.Member) {
+	member.Name = r.Name
+	member.Position = r.Position
+	member.ImageURL = r.ImageURL
+	member.Status = r.Status
+	if member.Status == "" {
+		member.Status = models.MemberStatusActive
+	}
+	if r.JoinedAt != nil {
+		member.JoinedAt = *r.JoinedAt
+	}
+	member.LeftAt = r.LeftAt
+	member.GraduationYear = r.GraduationYear
+	member.Bio = r.Bio
+	member.Department = r.Department
+	member.YearOfStudy = r.YearOfStudy
+	member.Skills = normalizeTags(r.Skills)
+}
+
+// normalizeTags lowercases tags such as skills and drops blanks and
+// duplicates so that "CAD" and "cad " are the same tag
+func normalizeTags(skills []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		skill = strings.ToLower(strings.TrimSpace(skill))
+		if skill == "" || seen[skill] {
+			continue
+		}
+		seen[skill] = true
+		normalized = append(normalized, skill)
+	}
+	return normalized
+}
+
+// syncContacts replaces the contacts of a member, keeping the given order
+func syncContacts(tx *gorm.DB, memberID uuid.UUID, contacts []ContactRequest) error {
+	if err := tx.Where("member_id = ?", memberID).Delete(&models.MemberContact{}).Error; err != nil {
+		return err
+	}
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	rows := make([]models.MemberContact, 0, len(contacts))
+	for i, contact := range contacts {
+		visibility := contact.Visibility
+		if visibility == "" {
+			visibility = models.ContactVisibilityPublic
+		}
+		rows = append(rows, models.MemberContact{
+			MemberID:   memberID,
+			Kind:       contact.Kind,
+			Value:      contact.Value,
+			Visibility: visibility,
+			Position:   i,
+		})
+	}
+	return tx.Create(&rows).Error
+}
+
+// withProfile preloads the position history (latest first), contacts,
+// certifications and competition achievements of a member
+func withProfile(db *gorm.DB) *gorm.DB {
+	return db.Preload("Positions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("started_at DESC")
+	}).Preload("Contacts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("Certifications", func(db *gorm.DB) *gorm.DB {
+		return db.Order("expires_at DESC NULLS FIRST")
+	}).Preload("Achievements", func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(listedAchievements).Order(achievementOrder)
+	}).Preload("Achievements.Competition").Preload("Achievements.Project")
+}
+
+// hidePrivateContacts drops private contacts from members unless the
+// request comes from an admin
+func hidePrivateContacts(c *gin.Context, members []models.Member) {
+	if middleware.AdminView(c) {
+		return
+	}
+	for i := range members {
+		public := []models.MemberContact{}
+		for _, contact := range members[i].Contacts {
+			if contact.Visibility == models.ContactVisibilityPublic {
+				public = append(public, contact)
+			}
+		}
+		members[i].Contacts = public
+	}
+}
+
+// trackLifecycle records what changed between two states of a member: a new
+// position closes the current term and opens another one, and leaving the
+// club closes the current term and records when they left
+func trackLifecycle(tx *gorm.DB, before, member *models.Member) error {
+	now := time.Now()
+	leaving := member.Status == models.MemberStatusAlumni && before.Status != models.MemberStatusAlumni
+	if leaving && member.LeftAt == nil {
+		member.LeftAt = &now
+	}
+	if !leaving && member.Position == before.Position {
+		return nil
+	}
+
+	if err := tx.Model(&models.MemberPosition{}).
+		Where("member_id = ? AND ended_at IS NULL", member.ID).
+		Update("ended_at", now).Error; err != nil {
+		return err
+	}
+	if leaving {
+		return nil
+	}
+	return tx.Create(&models.MemberPosition{
+		MemberID:  member.ID,
+		Title:     member.Position,
+		StartedAt: now,
+	}).Error
+}
+
+// saveMember applies a validated request to a member and writes the result
+func saveMember(c *gin.Context, member *models.Member, req *MemberRequest) {
+	before := *member
+	req.apply(member)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := trackLifecycle(tx, &before, member); err != nil {
+			return err
+		}
+		if err := saveVersioned(tx, member, &member.Version); err != nil {
+			return err
+		}
+		if err := syncContacts(tx, member.ID, req.Contacts); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.MemberUpdated, events.NewMemberData(member))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error updating member")
+		return
+	}
+
+	respondWithMember(c, http.StatusOK, member.ID)
+}
+
+// respondWithMember reloads a member after a write so the response and its
+// ETag match what a subsequent GET returns
+func respondWithMember(c *gin.Context, status int, id uuid.UUID) {
+	var member models.Member
+	if err := database.DB.Scopes(withProfile).First(&member, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching member")
+		return
+	}
+
+	respondWithETag(c, status, member)
+}
+
+// findMember loads a member with its position history by the :id path
+// parameter. On failure it writes the error envelope and returns false.
+func findMember(c *gin.Context, member *models.Member) bool {
+	id, ok := parseID(c, "member")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withProfile).First(member, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, "Member not found")
+		return false
+	}
+	return true
+}
+
+// filterMembers applies the status, graduationYear and position query
+// filters of the member listings
+func filterMembers(c *gin.Context, db *gorm.DB, defaultStatuses []string) (*gorm.DB, bool) {
+	statuses := defaultStatuses
+	if raw := c.Query("status"); raw == "all" {
+		statuses = nil
+	} else if raw != "" {
+		statuses = strings.Split(raw, ",")
+		for _, status := range statuses {
+			switch status {
+			case models.MemberStatusActive, models.MemberStatusAlumni, models.MemberStatusHonorary:
+			default:
+				apierror.Validation(c, []apierror.FieldError{{
+					Field:   "status",
+					Code:    "invalid_choice",
+					Message: "must be all or a comma-separated list of: active, alumni, honorary",
+				}})
+				return nil, false
+			}
+		}
+	}
+	if statuses != nil {
+		db = db.Where("status IN ?", statuses)
+	}
+
+	if raw := c.Query("graduationYear"); raw != "" {
+		year, err := strconv.Atoi(raw)
+		if err != nil {
+			apierror.Validation(c, []apierror.FieldError{{
+				Field:   "graduationYear",
+				Code:    "invalid_type",
+				Message: "must be a year",
+			}})
+			return nil, false
+		}
+		db = db.Where("graduation_year = ?", year)
+	}
+
+	if position := c.Query("position"); position != "" {
+		db = db.Where("position ILIKE ?", "%"+position+"%")
+	}
+
+	if skill := c.Query("skill"); skill != "" {
+		tags, _ := json.Marshal(normalizeTags([]string{skill}))
+		db = db.Where("skills @> ?::jsonb", string(tags))
+	}
+	return db, true
+}
+
+// GetMembers returns the members on the team page: active and honorary
+// members unless the status filter asks for others
 func GetMembers(c *gin.Context) {
+	db, ok := filterMembers(c, database.DB.Scopes(withProfile),
+		[]string{models.MemberStatusActive, models.MemberStatusHonorary})
+	if !ok {
+		return
+	}
+
 	var members []models.Member
-	result := database.DB.Find(&members)
+	result := db.Find(&members)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching members"})
+		apierror.Internal(c, "Error fetching members")
 		return
 	}
 
-	c.JSON(http.StatusOK, members)
+	hidePrivateContacts(c, members)
+	respondWithETag(c, http.StatusOK, members)
+}
+
+// GetAlumni returns former members, most recent graduates first
+func GetAlumni(c *gin.Context) {
+	db, ok := filterMembers(c, database.DB.Scopes(withProfile),
+		[]string{models.MemberStatusAlumni})
+	if !ok {
+		return
+	}
+
+	var members []models.Member
+	result := db.Order("graduation_year DESC NULLS LAST").Order("name").Find(&members)
+	if result.Error != nil {
+		apierror.Internal(c, "Error fetching alumni")
+		return
+	}
+
+	hidePrivateContacts(c, members)
+	respondWithETag(c, http.StatusOK, members)
 }
 
 // GetMember returns a specific member
 func GetMember(c *gin.Context) {
-	id := c.Param("id")
 	var member models.Member
-
-	if err := database.DB.First(&member, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+	if !findMember(c, &member) {
 		return
 	}
 
-	c.JSON(http.StatusOK, member)
+	members := []models.Member{member}
+	hidePrivateContacts(c, members)
+	respondWithETag(c, http.StatusOK, members[0])
+}
+
+// createMember inserts a member with their contacts and opens the term of
+// their first position
+func createMember(tx *gorm.DB, member *models.Member, contacts []ContactRequest) error {
+	if member.JoinedAt.IsZero() {
+		member.JoinedAt = time.Now()
+	}
+	if err := tx.Create(member).Error; err != nil {
+		return err
+	}
+	if err := syncContacts(tx, member.ID, contacts); err != nil {
+		return err
+	}
+	if member.Status != models.MemberStatusAlumni {
+		if err := tx.Create(&models.MemberPosition{
+			MemberID:  member.ID,
+			Title:     member.Position,
+			StartedAt: member.JoinedAt,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return events.Publish(tx, events.MemberCreated, events.NewMemberData(member))
 }
 
 // CreateMember creates a new member
 func CreateMember(c *gin.Context) {
-	var member models.Member
-	if err := c.ShouldBindJSON(&member); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req MemberRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	member.ID = uuid.New()
-	if err := database.DB.Create(&member).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating member"})
+	member := models.Member{ID: uuid.New()}
+	req.apply(&member)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return createMember(tx, &member, req.Contacts)
+	})
+	if err != nil {
+		apierror.Internal(c, "Error creating member")
 		return
 	}
 
-	c.JSON(http.StatusCreated, member)
+	respondWithMember(c, http.StatusCreated, member.ID)
 }
 
-// UpdateMember updates an existing member
+// UpdateMember replaces an existing member
 func UpdateMember(c *gin.Context) {
-	id := c.Param("id")
 	var member models.Member
+	if !findMember(c, &member) || !checkIfMatch(c, member) {
+		return
+	}
+
+	var req MemberRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	saveMember(c, &member, &req)
+}
+
+// PatchMember partially updates a member using JSON Merge Patch
+func PatchMember(c *gin.Context) {
+	var member models.Member
+	if !findMember(c, &member) || !checkIfMatch(c, member) {
+		return
+	}
+
+	req := newMemberRequest(&member)
+	if !bindMergePatch(c, &req) {
+		return
+	}
+
+	saveMember(c, &member, &req)
+}
+
+// PositionRequest is the body accepted when recording a past or current term
+type PositionRequest struct {
+	Title     string     `json:"title" binding:"required,max=255"`
+	StartedAt time.Time  `json:"startedAt" binding:"required"`
+	EndedAt   *time.Time `json:"endedAt" binding:"omitempty,gtfield=StartedAt"`
+}
+
+// AddMemberPosition records a term in a member's position history
+func AddMemberPosition(c *gin.Context) {
+	var member models.Member
+	if !findMember(c, &member) {
+		return
+	}
+
+	var req PositionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	position := models.MemberPosition{
+		MemberID:  member.ID,
+		Title:     req.Title,
+		StartedAt: req.StartedAt,
+		EndedAt:   req.EndedAt,
+	}
+	if err := database.DB.Create(&position).Error; err != nil {
+		apierror.Internal(c, "Error creating position")
+		return
+	}
+
+	c.JSON(http.StatusCreated, position)
+}
+
+// DeleteMemberPosition removes a term from a member's position history
+func DeleteMemberPosition(c *gin.Context) {
+	var member models.Member
+	if !findMember(c, &member) {
+		return
+	}
+
+	positionID, err := uuid.Parse(c.Param("positionId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid position ID format: %s", c.Param("positionId")))
+		return
+	}
+
+	result := database.DB.Where("member_id = ?", member.ID).
+		Delete(&models.MemberPosition{}, "id = ?", positionID)
+	if result.Error != nil {
+		apierror.Internal(c, "Error deleting position")
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.NotFound(c, fmt.Sprintf("Position not found with ID: %s", positionID))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Position deleted successfully"})
+}
+
+// CertificationRequest is the body accepted when recording a certification
+type CertificationRequest struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Issuer    string     `json:"issuer" binding:"max=255"`
+	IssuedAt  *time.Time `json:"issuedAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// AddMemberCertification records a certification held by a member
+func AddMemberCertification(c *gin.Context) {
+	var member models.Member
+	if !findMember(c, &member) {
+		return
+	}
+
+	var req CertificationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.IssuedAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.IssuedAt) {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "expiresAt",
+			Code:    "out_of_range",
+			Message: "must be after issuedAt",
+		}})
+		return
+	}
+
+	certification := models.MemberCertification{
+		MemberID:  member.ID,
+		Name:      req.Name,
+		Issuer:    req.Issuer,
+		IssuedAt:  req.IssuedAt,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := database.DB.Create(&certification).Error; err != nil {
+		apierror.Internal(c, "Error creating certification")
+		return
+	}
+	certification.AfterFind(database.DB)
 
-	if err := database.DB.First(&member, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+	c.JSON(http.StatusCreated, certification)
+}
+
+// DeleteMemberCertification removes a certification from a member
+func DeleteMemberCertification(c *gin.Context) {
+	var member models.Member
+	if !findMember(c, &member) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&member); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	certificationID, err := uuid.Parse(c.Param("certificationId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid certification ID format: %s", c.Param("certificationId")))
 		return
 	}
 
-	if err := database.DB.Save(&member).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating member"})
+	result := database.DB.Where("member_id = ?", member.ID).
+		Delete(&models.MemberCertification{}, "id = ?", certificationID)
+	if result.Error != nil {
+		apierror.Internal(c, "Error deleting certification")
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.NotFound(c, fmt.Sprintf("Certification not found with ID: %s", certificationID))
 		return
 	}
 
-	c.JSON(http.StatusOK, member)
+	c.JSON(http.StatusOK, gin.H{"message": "Certification deleted successfully"})
 }
 
-// DeleteMember deletes a member
+// Ways of handling the blogs of a member being deleted, chosen with the
+// authoredContent query parameter
+const (
+	// authoredBlock refuses to delete a member who still has blogs
+	authoredBlock = "block"
+	// authoredReassign moves the member's bylines to the reassignTo member
+	authoredReassign = "reassign"
+	// authoredAlumni keeps the member as alumni so their bylines stay intact
+	authoredAlumni = "alumni"
+)
+
+// AuthoredBlog is a blog that still credits a member being deleted
+type AuthoredBlog struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Trashed bool      `json:"trashed"`
+}
+
+// authoredBlogs lists every blog, including trashed ones, crediting a member
+func authoredBlogs(memberID uuid.UUID) ([]AuthoredBlog, error) {
+	var blogs []models.Blog
+	err := database.DB.Unscoped().
+		Where("id IN (?)", database.DB.Model(&models.BlogAuthor{}).
+			Select("blog_id").
+			Where("member_id = ?", memberID)).
+		Order("created_at").
+		Find(&blogs).Error
+
+	authored := make([]AuthoredBlog, 0, len(blogs))
+	for _, blog := range blogs {
+		authored = append(authored, AuthoredBlog{
+			ID:      blog.ID,
+			Title:   blog.Title,
+			Trashed: blog.DeletedAt.Valid,
+		})
+	}
+	return authored, err
+}
+
+// reassignBlogs replaces a member with another one in every byline they
+// appear in, keeping the byline order and dropping duplicates
+func reassignBlogs(tx *gorm.DB, from, to uuid.UUID, blogs []AuthoredBlog) error {
+	for _, authored := range blogs {
+		var blog models.Blog
+		if err := tx.Unscoped().First(&blog, "id = ?", authored.ID).Error; err != nil {
+			return err
+		}
+
+		var byline []uuid.UUID
+		if err := tx.Model(&models.BlogAuthor{}).
+			Where("blog_id = ?", blog.ID).
+			Order("position").
+			Pluck("member_id", &byline).Error; err != nil {
+			return err
+		}
+		for i, memberID := range byline {
+			if memberID == from {
+				byline[i] = to
+			}
+		}
+
+		blog.AuthorID = byline[0]
+		if err := tx.Unscoped().Model(&blog).Updates(map[string]any{
+			"author_id": blog.AuthorID,
+			"version":   gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if err := syncBlogAuthors(tx, &blog, byline[1:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteMember deletes a member. Members who still have blogs are handled
+// according to the authoredContent query parameter: "block" (the default)
+// answers 409 with the dependent blogs, "reassign" moves their bylines to the
+// member given in reassignTo, and "alumni" keeps them as an alumni author.
 func DeleteMember(c *gin.Context) {
-	id := c.Param("id")
 	var member models.Member
+	if !findMember(c, &member) || !checkIfMatch(c, member) {
+		return
+	}
+
+	mode := c.DefaultQuery("authoredContent", authoredBlock)
+	if mode != authoredBlock && mode != authoredReassign && mode != authoredAlumni {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "authoredContent",
+			Code:    "invalid_choice",
+			Message: "must be one of: block, reassign, alumni",
+		}})
+		return
+	}
 
-	if err := database.DB.First(&member, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+	var target models.Member
+	if mode == authoredReassign {
+		targetID, err := uuid.Parse(c.Query("reassignTo"))
+		if err != nil || targetID == member.ID ||
+			database.DB.First(&target, "id = ?", targetID).Error != nil {
+			apierror.Validation(c, []apierror.FieldError{{
+				Field:   "reassignTo",
+				Code:    "not_found",
+				Message: "must reference another existing member",
+			}})
+			return
+		}
+	}
+
+	blogs, err := authoredBlogs(member.ID)
+	if err != nil {
+		apierror.Internal(c, "Error checking authored blogs")
 		return
 	}
 
-	if err := database.DB.Delete(&member).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting member"})
+	if len(blogs) > 0 {
+		switch mode {
+		case authoredBlock:
+			apierror.ConflictWithDetails(c,
+				"Member still authors blogs; reassign them or keep the member as alumni",
+				gin.H{"blogs": blogs})
+			return
+
+		case authoredAlumni:
+			before := member
+			member.Status = models.MemberStatusAlumni
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := trackLifecycle(tx, &before, &member); err != nil {
+					return err
+				}
+				if err := saveVersioned(tx, &member, &member.Version); err != nil {
+					return err
+				}
+				return events.Publish(tx, events.MemberUpdated, events.NewMemberData(&member))
+			})
+			if err != nil {
+				respondWriteError(c, err, "Error updating member")
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Member kept as alumni so their blogs keep their byline",
+				"blogs":   blogs,
+			})
+			return
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reassignBlogs(tx, member.ID, target.ID, blogs); err != nil {
+			return err
+		}
+		if err := deleteVersioned(tx, &member, member.Version); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.MemberDeleted, events.NewMemberData(&member))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error deleting member")
 		return
 	}
 
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/mailer"
+	"avions-club/backend/models"
+	"avions-club/backend/newsletter"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// confirmResendDelay is how long a pending subscriber waits before signing up
+// again sends another confirmation email
+const confirmResendDelay = 10 * time.Minute
+
+// SubscribeRequest is the body of the public newsletter form. Website is a
+// honeypot, see honeypotTripped.
+type SubscribeRequest struct {
+	Email   string `json:"email" binding:"required,email,max=255"`
+	Name    string `json:"name" binding:"max=255"`
+	Website string `json:"website"`
+}
+
+// Subscribe signs an email address up for the newsletter and sends it a
+// confirmation link. The response is the same whether or not the address was
+// already subscribed, so the form cannot be used to probe the list.
+func Subscribe(c *gin.Context) {
+	var req SubscribeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if !newsletter.Enabled() {
+		apierror.Unavailable(c, "The newsletter is not available")
+		return
+	}
+
+	accepted := gin.H{"message": "Check your inbox to confirm your subscription"}
+	if honeypotTripped(c, req.Website, http.StatusAccepted, accepted) {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var subscriber models.Subscriber
+	err := database.DB.Where("email = ?", email).First(&subscriber).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		unsubscribeToken, _, err := newSecretToken()
+		if err != nil {
+			apierror.Internal(c, "Error creating subscription")
+			return
+		}
+		subscriber = models.Subscriber{Email: email, UnsubscribeToken: unsubscribeToken}
+	case err != nil:
+		apierror.Internal(c, "Error checking subscription")
+		return
+	case subscriber.Status == models.SubscriberStatusConfirmed:
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	case subscriber.Status == models.SubscriberStatusPending && subscriber.ConfirmSentAt != nil &&
+		time.Since(*subscriber.ConfirmSentAt) < confirmResendDelay:
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	token, hash, err := newSecretToken()
+	if err != nil {
+		apierror.Internal(c, "Error creating subscription")
+		return
+	}
+	now := time.Now()
+	subscriber.Name = strings.TrimSpace(req.Name)
+	subscriber.Status = models.SubscriberStatusPending
+	subscriber.ConfirmTokenHash = hash
+	subscriber.ConfirmSentAt = &now
+	subscriber.UnsubscribedAt = nil
+	subscriber.IPAddress = c.ClientIP()
+	if err := database.DB.Save(&subscriber).Error; err != nil {
+		apierror.Internal(c, "Error creating subscription")
+		return
+	}
+
+	if err := mailer.Send(newsletter.ConfirmationMessage(subscriber, token)); err != nil {
+		log.Printf("Error sending newsletter confirmation to %s: %v", subscriber.Email, err)
+		apierror.Internal(c, "Error sending confirmation email")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// ConfirmSubscription confirms a pending subscription with the token from
+// the confirmation email
+func ConfirmSubscription(c *gin.Context) {
+	var subscriber models.Subscriber
+	err := database.DB.Where("confirm_token_hash = ? AND status = ? AND confirm_sent_at > ?",
+		hashToken(c.Param("token")), models.SubscriberStatusPending,
+		time.Now().Add(-newsletter.ConfirmTokenLifetime)).
+		First(&subscriber).Error
+	if err != nil {
+		apierror.NotFound(c, "Confirmation link is invalid or has expired")
+		return
+	}
+
+	if err := database.DB.Model(&subscriber).Updates(map[string]any{
+		"status":             models.SubscriberStatusConfirmed,
+		"confirmed_at":       time.Now(),
+		"confirm_token_hash": "",
+	}).Error; err != nil {
+		apierror.Internal(c, "Error confirming subscription")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription confirmed"})
+}
+
+// Unsubscribe removes an address from the newsletter with the token linked
+// from every digest. Unsubscribing twice succeeds.
+func Unsubscribe(c *gin.Context) {
+	var subscriber models.Subscriber
+	if err := database.DB.Where("unsubscribe_token = ?", c.Param("token")).First(&subscriber).Error; err != nil {
+		apierror.NotFound(c, "Unsubscribe link is invalid")
+		return
+	}
+
+	if subscriber.Status != models.SubscriberStatusUnsubscribed {
+		if err := database.DB.Model(&subscriber).Updates(map[string]any{
+			"status":             models.SubscriberStatusUnsubscribed,
+			"unsubscribed_at":    time.Now(),
+			"confirm_token_hash": "",
+		}).Error; err != nil {
+			apierror.Internal(c, "Error unsubscribing")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have been unsubscribed"})
+}
+
+// GetSubscribers lists newsletter subscribers, newest first. status filters.
+func GetSubscribers(c *gin.Context) {
+	db := database.DB.Order("created_at DESC")
+	switch status := c.Query("status"); status {
+	case "":
+	case models.SubscriberStatusPending, models.SubscriberStatusConfirmed, models.SubscriberStatusUnsubscribed:
+		db = db.Where("status = ?", status)
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: pending, confirmed, unsubscribed",
+		}})
+		return
+	}
+
+	var subscribers []models.Subscriber
+	if err := db.Find(&subscribers).Error; err != nil {
+		apierror.Internal(c, "Error fetching subscribers")
+		return
+	}
+
+	c.JSON(http.StatusOK, subscribers)
+}
+
+// GetDigestRuns lists past digests, latest first
+func GetDigestRuns(c *gin.Context) {
+	var runs []models.DigestRun
+	if err := database.DB.Order("period_end DESC").Limit(100).Find(&runs).Error; err != nil {
+		apierror.Internal(c, "Error fetching digests")
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// SendDigest sends the digest now instead of waiting for the schedule
+func SendDigest(c *gin.Context) {
+	run, err := newsletter.SendDigest(database.DB, mailer.Default, time.Now())
+	if errors.Is(err, newsletter.ErrDisabled) {
+		apierror.Unavailable(c, "The newsletter is disabled until SITE_URL is set")
+		return
+	}
+	if err != nil {
+		log.Printf("Error sending the digest: %v", err)
+		apierror.Internal(c, "Error sending the digest")
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
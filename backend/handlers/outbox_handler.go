@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/events"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOutboxEvents lists stored domain events, newest first. status and name
+// filter; limit (default 50) caps the number of events.
+func GetOutboxEvents(c *gin.Context) {
+	limit, ok := parseIntQuery(c, "limit", 50, 1, 200)
+	if !ok {
+		return
+	}
+
+	db := database.DB.Order("created_at DESC").Limit(limit)
+	switch status := c.Query("status"); status {
+	case "":
+	case models.OutboxStatusPending, models.OutboxStatusProcessed, models.OutboxStatusFailed:
+		db = db.Where("status = ?", status)
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: pending, processed, failed",
+		}})
+		return
+	}
+	if name := c.Query("name"); name != "" {
+		db = db.Where("name = ?", name)
+	}
+
+	var stored []models.OutboxEvent
+	if err := db.Find(&stored).Error; err != nil {
+		apierror.Internal(c, "Error fetching events")
+		return
+	}
+
+	c.JSON(http.StatusOK, stored)
+}
+
+// RetryOutboxEvent hands a failed event again to the subscribers that did
+// not handle it
+func RetryOutboxEvent(c *gin.Context) {
+	id, ok := parseID(c, "event")
+	if !ok {
+		return
+	}
+
+	var stored models.OutboxEvent
+	if err := database.DB.First(&stored, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Event not found with ID: %s", id))
+		return
+	}
+	if stored.Status != models.OutboxStatusFailed {
+		apierror.Conflict(c, "Only failed events can be retried")
+		return
+	}
+
+	if err := events.Retry(database.DB, stored.ID); err != nil {
+		apierror.Internal(c, "Error retrying event")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Event queued for retry"})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// mergePatchContentType is the media type defined by RFC 7396
+const mergePatchContentType = "application/merge-patch+json"
+
+// bindMergePatch applies a JSON Merge Patch (RFC 7396) body onto req, which
+// must already hold the current state of the resource. Only the JSON fields of
+// req can be patched and a null value clears a field. The merged result is
+// validated with the same rules as a full replacement. On failure it writes
+// the error envelope and returns false.
+func bindMergePatch(c *gin.Context, req any) bool {
+	if ct := c.GetHeader("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != mergePatchContentType && mediaType != binding.MIMEJSON) {
+			apierror.Respond(c, http.StatusUnsupportedMediaType, apierror.CodeBadRequest,
+				"Content-Type must be "+mergePatchContentType)
+			return false
+		}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.BadRequest(c, "Error reading request body")
+		return false
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		apierror.BadRequest(c, "Request body must be a JSON object")
+		return false
+	}
+
+	current, err := json.Marshal(req)
+	if err != nil {
+		apierror.Internal(c, "Error preparing update")
+		return false
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(current, &doc); err != nil {
+		apierror.Internal(c, "Error preparing update")
+		return false
+	}
+
+	allowed := jsonFields(req)
+	var fields []apierror.FieldError
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !allowed[key] {
+			fields = append(fields, apierror.FieldError{
+				Field:   key,
+				Code:    "not_allowed",
+				Message: "cannot be modified",
+			})
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(patch[key]), []byte("null")) {
+			delete(doc, key)
+		} else {
+			doc[key] = patch[key]
+		}
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		apierror.Internal(c, "Error preparing update")
+		return false
+	}
+
+	// Start from the zero value so cleared fields do not keep their old value
+	target := reflect.ValueOf(req).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	if err := json.Unmarshal(merged, req); err != nil {
+		if fields, ok := validation.FieldErrors(err); ok {
+			apierror.Validation(c, fields)
+		} else {
+			apierror.BadRequest(c, "Invalid request body: "+err.Error())
+		}
+		return false
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		if fields, ok := validation.FieldErrors(err); ok {
+			apierror.Validation(c, fields)
+		} else {
+			apierror.BadRequest(c, err.Error())
+		}
+		return false
+	}
+	return true
+}
+
+// jsonFields returns the JSON names of the fields of the struct req points to
+func jsonFields(req any) map[string]bool {
+	t := reflect.TypeOf(req).Elem()
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
@@ -3,90 +3,184 @@ package handlers
 import (
 	"net/http"
 
+	"avions-club/backend/apierror"
 	"avions-club/backend/database"
+	"avions-club/backend/events"
 	"avions-club/backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ProjectRequest is the body accepted when creating or replacing a project
+type ProjectRequest struct {
+	Title       string `json:"title" binding:"required,max=255"`
+	Description string `json:"description" binding:"required,max=5000"`
+	MarkdownURL string `json:"markdownUrl" binding:"omitempty,max=2048,storageurl=markdown"`
+	ImageURL    string `json:"imageUrl" binding:"omitempty,max=2048,storageurl=images"`
+}
+
+// newProjectRequest returns the request that would recreate a project as it is
+func newProjectRequest(project *models.Project) ProjectRequest {
+	return ProjectRequest{
+		Title:       project.Title,
+		Description: project.Description,
+		MarkdownURL: project.MarkdownURL,
+		ImageURL:    project.ImageURL,
+	}
+}
+
+// apply copies the request onto a project
+func (r *ProjectRequest) apply(project *models.Project) {
+	project.Title = r.Title
+	project.Description = r.Description
+	project.MarkdownURL = r.MarkdownURL
+	project.ImageURL = r.ImageURL
+}
+
+// withTelemetry preloads the telemetry summaries of a project, oldest first
+func withTelemetry(db *gorm.DB) *gorm.DB {
+	return db.Preload("Telemetry", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	})
+}
+
+// findProject loads a project with its telemetry by the :id path parameter.
+// On failure it writes the error envelope and returns false.
+func findProject(c *gin.Context, project *models.Project) bool {
+	id, ok := parseID(c, "project")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Scopes(withTelemetry).First(project, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, "Project not found")
+		return false
+	}
+	return true
+}
+
+// respondWithProject reloads a project after a write so the response and its
+// ETag match what a subsequent GET returns
+func respondWithProject(c *gin.Context, status int, id uuid.UUID) {
+	var project models.Project
+	if err := database.DB.Scopes(withTelemetry).First(&project, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching project")
+		return
+	}
+
+	respondWithETag(c, status, project)
+}
+
 // GetProjects returns all projects
 func GetProjects(c *gin.Context) {
 	var projects []models.Project
 	result := database.DB.Find(&projects)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching projects"})
+		apierror.Internal(c, "Error fetching projects")
 		return
 	}
 
-	c.JSON(http.StatusOK, projects)
+	respondWithETag(c, http.StatusOK, projects)
 }
 
-// GetProject returns a specific project
+// GetProject returns a specific project with its telemetry summaries
 func GetProject(c *gin.Context) {
-	id := c.Param("id")
 	var project models.Project
-
-	if err := database.DB.First(&project, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	if !findProject(c, &project) {
 		return
 	}
 
-	c.JSON(http.StatusOK, project)
+	recordView(c, models.ContentTypeProject, project.ID)
+	respondWithETag(c, http.StatusOK, project)
 }
 
 // CreateProject creates a new project
 func CreateProject(c *gin.Context) {
-	var project models.Project
-	if err := c.ShouldBindJSON(&project); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req ProjectRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project := models.Project{ID: uuid.New()}
+	req.apply(&project)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		return events.Publish(tx, events.ProjectCreated, events.NewProjectData(&project))
+	})
+	if err != nil {
+		apierror.Internal(c, "Error creating project")
 		return
 	}
 
-	project.ID = uuid.New()
-	if err := database.DB.Create(&project).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating project"})
+	respondWithProject(c, http.StatusCreated, project.ID)
+}
+
+// saveProject applies a decoded request to a project and writes the result
+func saveProject(c *gin.Context, project *models.Project, req *ProjectRequest) {
+	req.apply(project)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, project, &project.Version); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.ProjectUpdated, events.NewProjectData(project))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error updating project")
 		return
 	}
 
-	c.JSON(http.StatusCreated, project)
+	respondWithProject(c, http.StatusOK, project.ID)
 }
 
-// UpdateProject updates an existing project
+// UpdateProject replaces an existing project
 func UpdateProject(c *gin.Context) {
-	id := c.Param("id")
 	var project models.Project
+	if !findProject(c, &project) || !checkIfMatch(c, project) {
+		return
+	}
 
-	if err := database.DB.First(&project, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	var req ProjectRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&project); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	saveProject(c, &project, &req)
+}
+
+// PatchProject partially updates a project using JSON Merge Patch
+func PatchProject(c *gin.Context) {
+	var project models.Project
+	if !findProject(c, &project) || !checkIfMatch(c, project) {
 		return
 	}
 
-	if err := database.DB.Save(&project).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating project"})
+	req := newProjectRequest(&project)
+	if !bindMergePatch(c, &req) {
 		return
 	}
 
-	c.JSON(http.StatusOK, project)
+	saveProject(c, &project, &req)
 }
 
 // DeleteProject deletes a project
 func DeleteProject(c *gin.Context) {
-	id := c.Param("id")
 	var project models.Project
-
-	if err := database.DB.First(&project, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	if !findProject(c, &project) || !checkIfMatch(c, project) {
 		return
 	}
 
-	if err := database.DB.Delete(&project).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting project"})
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteVersioned(tx, &project, project.Version); err != nil {
+			return err
+		}
+		return events.Publish(tx, events.ProjectDeleted, events.NewProjectData(&project))
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error deleting project")
 		return
 	}
 
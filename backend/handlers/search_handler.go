@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"avions-club/backend/apierror"
 	"avions-club/backend/database"
 	"avions-club/backend/models"
 	"net/http"
@@ -17,14 +18,20 @@ type SearchResponse struct {
 func Search(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		apierror.BadRequest(c, "Search query is required")
 		return
 	}
 
 	var response SearchResponse
 
 	// Search in members
-	database.DB.Where("name ILIKE ? OR position ILIKE ?", "%"+query+"%", "%"+query+"%").
+	database.DB.Where("status IN ?", []string{models.MemberStatusActive, models.MemberStatusHonorary}).
+		Where(database.DB.
+			Where("name ILIKE ? OR position ILIKE ?", "%"+query+"%", "%"+query+"%").
+			Or("bio ILIKE ? OR department ILIKE ? OR skills::text ILIKE ?", "%"+query+"%", "%"+query+"%", "%"+query+"%").
+			Or("id IN (?)", database.DB.Model(&models.MemberCertification{}).
+				Select("member_id").
+				Where("name ILIKE ?", "%"+query+"%"))).
 		Find(&response.Members)
 
 	// Search in projects
@@ -32,9 +39,9 @@ func Search(c *gin.Context) {
 		Find(&response.Projects)
 
 	// Search in blogs
-	database.DB.Preload("Author").
+	database.DB.Scopes(withAuthors).
 		Where("title ILIKE ? OR description ILIKE ?", "%"+query+"%", "%"+query+"%").
 		Find(&response.Blogs)
 
-	c.JSON(http.StatusOK, response)
+	respondWithETag(c, http.StatusOK, response)
 }
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/middleware"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sponsorOrder lists sponsors by tier, then by their display order
+const sponsorOrder = `CASE tier
+	WHEN 'platinum' THEN 0
+	WHEN 'gold' THEN 1
+	WHEN 'silver' THEN 2
+	WHEN 'bronze' THEN 3
+	ELSE 4 END, display_order, name`
+
+// SponsorRequest is the body accepted when creating or replacing a sponsor
+type SponsorRequest struct {
+	Name         string      `json:"name" binding:"required,max=255"`
+	Tier         string      `json:"tier" binding:"required,oneof=platinum gold silver bronze partner"`
+	Description  string      `json:"description" binding:"max=5000"`
+	LogoURL      string      `json:"logoUrl" binding:"omitempty,max=2048,storageurl=images"`
+	WebsiteURL   string      `json:"websiteUrl" binding:"omitempty,max=2048,http_url"`
+	StartsOn     time.Time   `json:"startsOn" binding:"required"`
+	EndsOn       *time.Time  `json:"endsOn" binding:"omitempty,gtefield=StartsOn"`
+	DisplayOrder int         `json:"displayOrder"`
+	ProjectIDs   []uuid.UUID `json:"projectIds" binding:"omitempty,max=50,dive,required"`
+}
+
+// newSponsorRequest returns the request that would recreate a sponsor as it is
+func newSponsorRequest(sponsor *models.Sponsor) SponsorRequest {
+	req := SponsorRequest{
+		Name:         sponsor.Name,
+		Tier:         sponsor.Tier,
+		Description:  sponsor.Description,
+		LogoURL:      sponsor.LogoURL,
+		WebsiteURL:   sponsor.WebsiteURL,
+		StartsOn:     sponsor.StartsOn,
+		EndsOn:       sponsor.EndsOn,
+		DisplayOrder: sponsor.DisplayOrder,
+	}
+	for _, project := range sponsor.Projects {
+		req.ProjectIDs = append(req.ProjectIDs, project.ID)
+	}
+	return req
+}
+
+// apply copies the request onto a sponsor
+func (r *SponsorRequest) apply(sponsor *models.Sponsor) {
+	sponsor.Name = r.Name
+	sponsor.Tier = r.Tier
+	sponsor.Description = r.Description
+	sponsor.LogoURL = r.LogoURL
+	sponsor.WebsiteURL = r.WebsiteURL
+	sponsor.StartsOn = r.StartsOn
+	sponsor.EndsOn = r.EndsOn
+	sponsor.DisplayOrder = r.DisplayOrder
+}
+
+// checkSponsorRequest verifies the funded projects of a decoded sponsor
+// request. On failure it writes the error envelope and returns false.
+func checkSponsorRequest(c *gin.Context, req *SponsorRequest) bool {
+	exists, err := existingIDs(&models.Project{}, req.ProjectIDs)
+	if err != nil {
+		apierror.Internal(c, "Error checking sponsor projects")
+		return false
+	}
+	if fields := missingIDFields("projectIds", req.ProjectIDs, exists, "must reference an existing project"); len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// withSponsorProjects preloads the projects a sponsor funded
+func withSponsorProjects(db *gorm.DB) *gorm.DB {
+	return db.Preload("Projects")
+}
+
+// activeSponsors limits sponsors to those whose date range includes today
+func activeSponsors(db *gorm.DB) *gorm.DB {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return db.Where("starts_on <= ? AND (ends_on IS NULL OR ends_on >= ?)", today, today)
+}
+
+// findSponsor loads a sponsor with its projects by the :id path parameter.
+// Only admins can see sponsors that are not active. On failure it writes the
+// error envelope and returns false.
+func findSponsor(c *gin.Context, sponsor *models.Sponsor) bool {
+	id, ok := parseID(c, "sponsor")
+	if !ok {
+		return false
+	}
+
+	db := database.DB.Scopes(withSponsorProjects)
+	if !middleware.AdminView(c) {
+		db = db.Scopes(activeSponsors)
+	}
+	if err := db.First(sponsor, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Sponsor not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// respondWithSponsor reloads a sponsor after a write so the response and its
+// ETag match what a subsequent GET returns
+func respondWithSponsor(c *gin.Context, status int, id uuid.UUID) {
+	var sponsor models.Sponsor
+	if err := database.DB.Scopes(withSponsorProjects).First(&sponsor, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching sponsor")
+		return
+	}
+
+	respondWithETag(c, status, sponsor)
+}
+
+// GetSponsors returns the active sponsors by tier and display order.
+// projectId filters; admins can list every sponsor with status=all.
+func GetSponsors(c *gin.Context) {
+	db := database.DB.Scopes(withSponsorProjects).Order(sponsorOrder)
+	switch c.DefaultQuery("status", "active") {
+	case "active":
+		db = db.Scopes(activeSponsors)
+	case "all":
+		if !middleware.AdminView(c) {
+			apierror.Forbidden(c, "Only admins can list inactive sponsors")
+			return
+		}
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: active, all",
+		}})
+		return
+	}
+
+	if projectID := c.Query("projectId"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			apierror.BadRequest(c, fmt.Sprintf("Invalid project ID format: %s", projectID))
+			return
+		}
+		db = db.Where("id IN (?)", database.DB.Table("sponsor_projects").
+			Select("sponsor_id").
+			Where("project_id = ?", id))
+	}
+
+	var sponsors []models.Sponsor
+	if err := db.Find(&sponsors).Error; err != nil {
+		apierror.Internal(c, "Error fetching sponsors")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, sponsors)
+}
+
+// GetSponsor returns a specific sponsor
+func GetSponsor(c *gin.Context) {
+	var sponsor models.Sponsor
+	if !findSponsor(c, &sponsor) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, sponsor)
+}
+
+// saveSponsorProjects replaces the projects a sponsor funded
+func saveSponsorProjects(tx *gorm.DB, sponsor *models.Sponsor, projectIDs []uuid.UUID) error {
+	projects := make([]models.Project, 0, len(projectIDs))
+	for _, id := range projectIDs {
+		projects = append(projects, models.Project{ID: id})
+	}
+	return tx.Model(sponsor).Omit("Projects.*").Association("Projects").Replace(projects)
+}
+
+// CreateSponsor creates a new sponsor
+func CreateSponsor(c *gin.Context) {
+	var req SponsorRequest
+	if !bindJSON(c, &req) || !checkSponsorRequest(c, &req) {
+		return
+	}
+
+	sponsor := models.Sponsor{ID: uuid.New()}
+	req.apply(&sponsor)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&sponsor).Error; err != nil {
+			return err
+		}
+		return saveSponsorProjects(tx, &sponsor, req.ProjectIDs)
+	})
+	if err != nil {
+		apierror.Internal(c, "Error creating sponsor")
+		return
+	}
+
+	respondWithSponsor(c, http.StatusCreated, sponsor.ID)
+}
+
+// saveSponsor applies a validated request to a sponsor and writes the result
+func saveSponsor(c *gin.Context, sponsor *models.Sponsor, req *SponsorRequest) {
+	req.apply(sponsor)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, sponsor, &sponsor.Version); err != nil {
+			return err
+		}
+		return saveSponsorProjects(tx, sponsor, req.ProjectIDs)
+	})
+	if err != nil {
+		respondWriteError(c, err, "Error updating sponsor")
+		return
+	}
+
+	respondWithSponsor(c, http.StatusOK, sponsor.ID)
+}
+
+// UpdateSponsor replaces an existing sponsor
+func UpdateSponsor(c *gin.Context) {
+	var sponsor models.Sponsor
+	if !findSponsor(c, &sponsor) || !checkIfMatch(c, sponsor) {
+		return
+	}
+
+	var req SponsorRequest
+	if !bindJSON(c, &req) || !checkSponsorRequest(c, &req) {
+		return
+	}
+
+	saveSponsor(c, &sponsor, &req)
+}
+
+// PatchSponsor partially updates a sponsor using JSON Merge Patch
+func PatchSponsor(c *gin.Context) {
+	var sponsor models.Sponsor
+	if !findSponsor(c, &sponsor) || !checkIfMatch(c, sponsor) {
+		return
+	}
+
+	req := newSponsorRequest(&sponsor)
+	if !bindMergePatch(c, &req) || !checkSponsorRequest(c, &req) {
+		return
+	}
+
+	saveSponsor(c, &sponsor, &req)
+}
+
+// DeleteSponsor deletes a sponsor
+func DeleteSponsor(c *gin.Context) {
+	var sponsor models.Sponsor
+	if !findSponsor(c, &sponsor) || !checkIfMatch(c, sponsor) {
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &sponsor, sponsor.Version); err != nil {
+		respondWriteError(c, err, "Error deleting sponsor")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sponsor deleted successfully"})
+}
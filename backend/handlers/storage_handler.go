@@ -10,6 +10,9 @@ import (
 	"regexp"
 	"strings"
 
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/events"
 	"avions-club/backend/storage"
 
 	"github.com/gin-gonic/gin"
@@ -43,13 +46,30 @@ var allowedMarkdownTypes = map[string]bool{
 	"application/x-markdown": true,
 }
 
+// publishUpload records a FileUploaded event for a file stored at url. The
+// file is already in storage, so a failure is logged rather than returned.
+func publishUpload(url string) {
+	bucket, filename, ok := storage.ObjectFromURL(url)
+	if !ok {
+		return
+	}
+	data := events.FileData{Bucket: bucket, Filename: filename, URL: url}
+	if err := events.Publish(database.DB, events.FileUploaded, data); err != nil {
+		log.Printf("Error publishing upload of %s: %v", url, err)
+	}
+}
+
 // UploadFile handles file uploads
 func UploadFile(c *gin.Context) {
 	// Get file from request
 	file, err := c.FormFile("file")
 	if err != nil {
 		log.Printf("Error getting file: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "file",
+			Code:    "required",
+			Message: "is required",
+		}})
 		return
 	}
 
@@ -64,14 +84,22 @@ func UploadFile(c *gin.Context) {
 		case ".md":
 			fileType = "markdown"
 		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type"})
+			apierror.Validation(c, []apierror.FieldError{{
+				Field:   "file",
+				Code:    "invalid_type",
+				Message: "must be an image or a markdown file",
+			}})
 			return
 		}
 	}
 
 	// Check file size
 	if file.Size > 5<<20 { // 5MB limit
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "file",
+			Code:    "too_large",
+			Message: "must be at most 5MB",
+		}})
 		return
 	}
 
@@ -83,9 +111,10 @@ func UploadFile(c *gin.Context) {
 	url, err := storage.UploadFile(file, filename)
 	if err != nil {
 		log.Printf("Error uploading file: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		apierror.Internal(c, "Failed to upload file")
 		return
 	}
+	publishUpload(url)
 
 	c.JSON(http.StatusOK, ImageUploadResponse{
 		URL:      url,
@@ -99,7 +128,7 @@ func ProcessMarkdownContent(c *gin.Context) {
 		Content string `json:"content"`
 	}
 	if err := c.ShouldBindJSON(&content); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		apierror.BadRequest(c, "Invalid request body")
 		return
 	}
 
@@ -168,15 +197,19 @@ func DeleteFile(c *gin.Context) {
 	filename := c.Param("filename")
 
 	if bucket != "images" && bucket != "markdown" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket"})
+		apierror.BadRequest(c, "Invalid bucket")
 		return
 	}
 
 	err := storage.DeleteFile(bucket, filename)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting file"})
+		apierror.Internal(c, "Error deleting file")
 		return
 	}
+	data := events.FileData{Bucket: bucket, Filename: filename}
+	if err := events.Publish(database.DB, events.FileDeleted, data); err != nil {
+		log.Printf("Error publishing deletion of %s/%s: %v", bucket, filename, err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "File deleted successfully"})
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+	"avions-club/backend/telemetry"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxTelemetrySize is the largest telemetry file accepted for parsing
+const maxTelemetrySize = 25 << 20
+
+// UploadTelemetry parses a flight-controller log and stores its summary on a
+// project. The file is not kept.
+func UploadTelemetry(c *gin.Context) {
+	var project models.Project
+	if !findProject(c, &project) {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "file",
+			Code:    "required",
+			Message: "is required",
+		}})
+		return
+	}
+	if file.Size > maxTelemetrySize {
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "file",
+			Code:    "too_large",
+			Message: "must be at most 25MB",
+		}})
+		return
+	}
+
+	parse := telemetry.ParseTLog
+	format := models.TelemetryFormatTLog
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".tlog":
+	case ".csv":
+		parse = telemetry.ParseCSV
+		format = models.TelemetryFormatCSV
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "file",
+			Code:    "invalid_type",
+			Message: "must be a MAVLink .tlog or a .csv export",
+		}})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		apierror.BadRequest(c, "Error reading uploaded file")
+		return
+	}
+	defer src.Close()
+
+	summary, err := parse(src)
+	if err != nil {
+		log.Printf("Error parsing telemetry %s: %v", file.Filename, err)
+		message := "could not be read as telemetry"
+		if errors.Is(err, telemetry.ErrNoData) {
+			message = "contains no altitude, speed or battery data"
+		}
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "file",
+			Code:    "invalid",
+			Message: message,
+		}})
+		return
+	}
+
+	telemetryLog := models.TelemetryLog{
+		ProjectID: project.ID,
+		Filename:  filepath.Base(file.Filename),
+		Format:    format,
+		SizeBytes: file.Size,
+		Summary:   *summary,
+	}
+	if err := database.DB.Create(&telemetryLog).Error; err != nil {
+		apierror.Internal(c, "Error saving telemetry")
+		return
+	}
+
+	c.JSON(http.StatusCreated, telemetryLog)
+}
+
+// DeleteTelemetry removes a telemetry summary from a project
+func DeleteTelemetry(c *gin.Context) {
+	var project models.Project
+	if !findProject(c, &project) {
+		return
+	}
+
+	telemetryID, err := uuid.Parse(c.Param("telemetryId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid telemetry ID format: %s", c.Param("telemetryId")))
+		return
+	}
+
+	result := database.DB.Where("project_id = ?", project.ID).
+		Delete(&models.TelemetryLog{}, "id = ?", telemetryID)
+	if result.Error != nil {
+		apierror.Internal(c, "Error deleting telemetry")
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.NotFound(c, fmt.Sprintf("Telemetry not found with ID: %s", telemetryID))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Telemetry deleted successfully"})
+}
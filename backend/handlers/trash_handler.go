@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+	"avions-club/backend/trash"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TrashEntry is a soft-deleted item along with when it will be purged
+type TrashEntry struct {
+	Item      any       `json:"item"`
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// trashed constrains the type parameters of the trash helpers to pointers to
+// models that can be trashed
+type trashed[T any] interface {
+	*T
+	models.Trashable
+}
+
+// listTrash returns every soft-deleted row of a model, most recent first
+func listTrash[T any, PT trashed[T]](c *gin.Context, db *gorm.DB, entity string) {
+	var items []T
+	if err := db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&items).Error; err != nil {
+		apierror.Internal(c, fmt.Sprintf("Error fetching deleted %ss", entity))
+		return
+	}
+
+	retention := trash.Retention()
+	entries := make([]TrashEntry, 0, len(items))
+	for i := range items {
+		deletedAt := PT(&items[i]).TrashedAt()
+		entries = append(entries, TrashEntry{
+			Item:      items[i],
+			DeletedAt: deletedAt,
+			PurgeAt:   deletedAt.Add(retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// findTrashed loads a soft-deleted row by the :id path parameter. On failure
+// it writes the error envelope and returns false.
+func findTrashed[T any](c *gin.Context, entity string, item *T) bool {
+	id, ok := parseID(c, entity)
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(item, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("No deleted %s found with ID: %s", entity, id))
+		return false
+	}
+	return true
+}
+
+// restoreTrashed brings a soft-deleted row back
+func restoreTrashed[T any, PT trashed[T]](c *gin.Context, entity string) {
+	var item T
+	if !findTrashed(c, entity, &item) {
+		return
+	}
+
+	if err := trash.Restore(database.DB, PT(&item)); err != nil {
+		apierror.Internal(c, fmt.Sprintf("Error restoring %s", entity))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Restored %s successfully", entity)})
+}
+
+// purgeTrashed permanently deletes a soft-deleted row and its files
+func purgeTrashed[T any, PT trashed[T]](c *gin.Context, entity string) {
+	var item T
+	if !findTrashed(c, entity, &item) {
+		return
+	}
+
+	err := trash.Purge(database.DB, PT(&item))
+	if errors.Is(err, trash.ErrInUse) {
+		apierror.Conflict(c, fmt.Sprintf("The %s is still referenced by other content", entity))
+		return
+	}
+	if err != nil {
+		apierror.Internal(c, fmt.Sprintf("Error purging %s", entity))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Purged %s permanently", entity)})
+}
+
+// GetTrashedMembers lists deleted members
+func GetTrashedMembers(c *gin.Context) {
+	listTrash[models.Member](c, database.DB, "member")
+}
+
+// RestoreMember restores a deleted member
+func RestoreMember(c *gin.Context) {
+	restoreTrashed[models.Member](c, "member")
+}
+
+// PurgeMember permanently deletes a member from the trash
+func PurgeMember(c *gin.Context) {
+	purgeTrashed[models.Member](c, "member")
+}
+
+// GetTrashedProjects lists deleted projects
+func GetTrashedProjects(c *gin.Context) {
+	listTrash[models.Project](c, database.DB, "project")
+}
+
+// RestoreProject restores a deleted project
+func RestoreProject(c *gin.Context) {
+	restoreTrashed[models.Project](c, "project")
+}
+
+// PurgeProject permanently deletes a project from the trash
+func PurgeProject(c *gin.Context) {
+	purgeTrashed[models.Project](c, "project")
+}
+
+// GetTrashedBlogs lists deleted blogs with their authors
+func GetTrashedBlogs(c *gin.Context) {
+	listTrash[models.Blog](c, database.DB.Scopes(withAuthors), "blog")
+}
+
+// RestoreBlog restores a deleted blog
+func RestoreBlog(c *gin.Context) {
+	restoreTrashed[models.Blog](c, "blog")
+}
+
+// PurgeBlog permanently deletes a blog from the trash
+func PurgeBlog(c *gin.Context) {
+	purgeTrashed[models.Blog](c, "blog")
+}
+
+// GetTrashedEvents lists deleted events
+func GetTrashedEvents(c *gin.Context) {
+	listTrash[models.Event](c, database.DB, "event")
+}
+
+// RestoreEvent restores a deleted event
+func RestoreEvent(c *gin.Context) {
+	restoreTrashed[models.Event](c, "event")
+}
+
+// PurgeEvent permanently deletes an event from the trash along with its RSVPs
+func PurgeEvent(c *gin.Context) {
+	purgeTrashed[models.Event](c, "event")
+}
+
+// GetTrashedFlightLogs lists deleted flight logs
+func GetTrashedFlightLogs(c *gin.Context) {
+	listTrash[models.FlightLog](c, database.DB, "flight log")
+}
+
+// RestoreFlightLog restores a deleted flight log
+func RestoreFlightLog(c *gin.Context) {
+	restoreTrashed[models.FlightLog](c, "flight log")
+}
+
+// PurgeFlightLog permanently deletes a flight log from the trash
+func PurgeFlightLog(c *gin.Context) {
+	purgeTrashed[models.FlightLog](c, "flight log")
+}
+
+// GetTrashedInventoryItems lists deleted inventory items
+func GetTrashedInventoryItems(c *gin.Context) {
+	listTrash[models.InventoryItem](c, database.DB, "item")
+}
+
+// RestoreInventoryItem restores a deleted inventory item
+func RestoreInventoryItem(c *gin.Context) {
+	restoreTrashed[models.InventoryItem](c, "item")
+}
+
+// PurgeInventoryItem permanently deletes an inventory item from the trash
+// along with its checkout history
+func PurgeInventoryItem(c *gin.Context) {
+	purgeTrashed[models.InventoryItem](c, "item")
+}
+
+// GetTrashedSponsors lists deleted sponsors with their projects
+func GetTrashedSponsors(c *gin.Context) {
+	listTrash[models.Sponsor](c, database.DB.Scopes(withSponsorProjects), "sponsor")
+}
+
+// RestoreSponsor restores a deleted sponsor
+func RestoreSponsor(c *gin.Context) {
+	restoreTrashed[models.Sponsor](c, "sponsor")
+}
+
+// PurgeSponsor permanently deletes a sponsor from the trash
+func PurgeSponsor(c *gin.Context) {
+	purgeTrashed[models.Sponsor](c, "sponsor")
+}
+
+// GetTrashedCompetitions lists deleted competitions with their achievements
+func GetTrashedCompetitions(c *gin.Context) {
+	listTrash[models.Competition](c, database.DB.Preload("Achievements"), "competition")
+}
+
+// RestoreCompetition restores a deleted competition
+func RestoreCompetition(c *gin.Context) {
+	restoreTrashed[models.Competition](c, "competition")
+}
+
+// PurgeCompetition permanently deletes a competition from the trash along
+// with its achievements
+func PurgeCompetition(c *gin.Context) {
+	purgeTrashed[models.Competition](c, "competition")
+}
+
+// GetTrashedAlbums lists deleted albums with their photos
+func GetTrashedAlbums(c *gin.Context) {
+	listTrash[models.Album](c, database.DB.Scopes(withAlbumPhotos), "album")
+}
+
+// RestoreAlbum restores a deleted album
+func RestoreAlbum(c *gin.Context) {
+	restoreTrashed[models.Album](c, "album")
+}
+
+// PurgeAlbum permanently deletes an album from the trash along with its
+// photos
+func PurgeAlbum(c *gin.Context) {
+	purgeTrashed[models.Album](c, "album")
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/database"
+	"avions-club/backend/events"
+	"avions-club/backend/models"
+	"avions-club/backend/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookRequest is the body accepted when creating or replacing a webhook.
+// Secret is generated when left out on create and kept when left out on
+// update.
+type WebhookRequest struct {
+	Name   string   `json:"name" binding:"required,max=255"`
+	URL    string   `json:"url" binding:"required,max=2048,http_url"`
+	Format string   `json:"format" binding:"omitempty,oneof=json discord"`
+	Events []string `json:"events" binding:"required,min=1,max=50,dive,required"`
+	Active *bool    `json:"active"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=128"`
+}
+
+// WebhookWithSecret is a webhook along with its signing secret, returned
+// only when the webhook is created or its secret is changed
+type WebhookWithSecret struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// newWebhookRequest returns the request that would recreate a webhook as it
+// is, without revealing its secret
+func newWebhookRequest(webhook *models.Webhook) WebhookRequest {
+	return WebhookRequest{
+		Name:   webhook.Name,
+		URL:    webhook.URL,
+		Format: webhook.Format,
+		Events: webhook.Events,
+		Active: &webhook.Active,
+	}
+}
+
+// apply copies the request onto a webhook
+func (r *WebhookRequest) apply(webhook *models.Webhook) {
+	webhook.Name = r.Name
+	webhook.URL = r.URL
+	webhook.Format = r.Format
+	if webhook.Format == "" {
+		webhook.Format = models.WebhookFormatJSON
+	}
+	webhook.Events = r.Events
+	webhook.Active = r.Active == nil || *r.Active
+	if r.Secret != "" {
+		webhook.Secret = r.Secret
+	}
+}
+
+// checkWebhookRequest verifies the events of a decoded webhook request. On
+// failure it writes the error envelope and returns false.
+func checkWebhookRequest(c *gin.Context, req *WebhookRequest) bool {
+	var fields []apierror.FieldError
+	for i, event := range req.Events {
+		if event != webhooks.AllEvents && !slices.Contains(events.Names, event) {
+			fields = append(fields, apierror.FieldError{
+				Field:   fmt.Sprintf("events[%d]", i),
+				Code:    "invalid_choice",
+				Message: "must be * or one of: " + strings.Join(events.Names, ", "),
+			})
+		}
+	}
+	if len(fields) > 0 {
+		apierror.Validation(c, fields)
+		return false
+	}
+	return true
+}
+
+// findWebhook loads a webhook by the :id path parameter. On failure it
+// writes the error envelope and returns false.
+func findWebhook(c *gin.Context, webhook *models.Webhook) bool {
+	id, ok := parseID(c, "webhook")
+	if !ok {
+		return false
+	}
+
+	if err := database.DB.First(webhook, "id = ?", id).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Webhook not found with ID: %s", id))
+		return false
+	}
+	return true
+}
+
+// findWebhookDelivery loads a delivery of a webhook by the :deliveryId path
+// parameter. On failure it writes the error envelope and returns false.
+func findWebhookDelivery(c *gin.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) bool {
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		apierror.BadRequest(c, fmt.Sprintf("Invalid delivery ID format: %s", c.Param("deliveryId")))
+		return false
+	}
+
+	if err := database.DB.First(delivery, "id = ? AND webhook_id = ?", deliveryID, webhook.ID).Error; err != nil {
+		apierror.NotFound(c, fmt.Sprintf("Delivery not found with ID: %s", deliveryID))
+		return false
+	}
+	return true
+}
+
+// respondWithWebhook reloads a webhook after a write so the response and its
+// ETag match what a subsequent GET returns. The secret is included when
+// withSecret is set.
+func respondWithWebhook(c *gin.Context, status int, id uuid.UUID, withSecret bool) {
+	var webhook models.Webhook
+	if err := database.DB.First(&webhook, "id = ?", id).Error; err != nil {
+		apierror.Internal(c, "Error fetching webhook")
+		return
+	}
+
+	if withSecret {
+		// The ETag covers the webhook alone so it matches a subsequent GET
+		tag, err := entityTag(webhook)
+		if err != nil {
+			apierror.Internal(c, "Error encoding webhook")
+			return
+		}
+		c.Header("ETag", tag)
+		c.JSON(status, WebhookWithSecret{Webhook: webhook, Secret: webhook.Secret})
+		return
+	}
+	respondWithETag(c, status, webhook)
+}
+
+// GetWebhooks returns every webhook, oldest first
+func GetWebhooks(c *gin.Context) {
+	var hooks []models.Webhook
+	if err := database.DB.Order("created_at").Find(&hooks).Error; err != nil {
+		apierror.Internal(c, "Error fetching webhooks")
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, hooks)
+}
+
+// GetWebhook returns a specific webhook
+func GetWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if !findWebhook(c, &webhook) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, webhook)
+}
+
+// CreateWebhook subscribes a URL to events. The response holds the signing
+// secret, which is not shown again.
+func CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if !bindJSON(c, &req) || !checkWebhookRequest(c, &req) {
+		return
+	}
+
+	webhook := models.Webhook{ID: uuid.New()}
+	req.apply(&webhook)
+	if webhook.Secret == "" {
+		secret, _, err := newSecretToken()
+		if err != nil {
+			apierror.Internal(c, "Error creating webhook")
+			return
+		}
+		webhook.Secret = secret
+	}
+	if err := database.DB.Create(&webhook).Error; err != nil {
+		apierror.Internal(c, "Error creating webhook")
+		return
+	}
+
+	respondWithWebhook(c, http.StatusCreated, webhook.ID, true)
+}
+
+// saveWebhook applies a validated request to a webhook and writes the result
+func saveWebhook(c *gin.Context, webhook *models.Webhook, req *WebhookRequest) {
+	req.apply(webhook)
+	if err := saveVersioned(database.DB, webhook, &webhook.Version); err != nil {
+		respondWriteError(c, err, "Error updating webhook")
+		return
+	}
+
+	respondWithWebhook(c, http.StatusOK, webhook.ID, req.Secret != "")
+}
+
+// UpdateWebhook replaces an existing webhook
+func UpdateWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if !findWebhook(c, &webhook) || !checkIfMatch(c, webhook) {
+		return
+	}
+
+	var req WebhookRequest
+	if !bindJSON(c, &req) || !checkWebhookRequest(c, &req) {
+		return
+	}
+
+	saveWebhook(c, &webhook, &req)
+}
+
+// PatchWebhook partially updates a webhook using JSON Merge Patch
+func PatchWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if !findWebhook(c, &webhook) || !checkIfMatch(c, webhook) {
+		return
+	}
+
+	req := newWebhookRequest(&webhook)
+	if !bindMergePatch(c, &req) || !checkWebhookRequest(c, &req) {
+		return
+	}
+
+	saveWebhook(c, &webhook, &req)
+}
+
+// DeleteWebhook deletes a webhook along with its delivery log
+func DeleteWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if !findWebhook(c, &webhook) || !checkIfMatch(c, webhook) {
+		return
+	}
+
+	if err := deleteVersioned(database.DB, &webhook, webhook.Version); err != nil {
+		respondWriteError(c, err, "Error deleting webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// PingWebhook sends a ping event to a webhook to check that it is reachable
+func PingWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if !findWebhook(c, &webhook) {
+		return
+	}
+	if !webhook.Active {
+		apierror.Conflict(c, "Webhook is disabled; enable it before pinging")
+		return
+	}
+
+	delivery, err := webhooks.Ping(webhook)
+	if err != nil {
+		apierror.Internal(c, "Error queueing ping")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, newest first.
+// status and event filter; limit (default 50) caps the number of entries.
+func GetWebhookDeliveries(c *gin.Context) {
+	var webhook models.Webhook
+	if !findWebhook(c, &webhook) {
+		return
+	}
+	limit, ok := parseIntQuery(c, "limit", 50, 1, 200)
+	if !ok {
+		return
+	}
+
+	db := database.DB.Where("webhook_id = ?", webhook.ID).Order("created_at DESC").Limit(limit)
+	switch status := c.Query("status"); status {
+	case "":
+	case models.DeliveryStatusPending, models.DeliveryStatusSucceeded, models.DeliveryStatusFailed:
+		db = db.Where("status = ?", status)
+	default:
+		apierror.Validation(c, []apierror.FieldError{{
+			Field:   "status",
+			Code:    "invalid_choice",
+			Message: "must be one of: pending, succeeded, failed",
+		}})
+		return
+	}
+	if event := c.Query("event"); event != "" {
+		db = db.Where("event = ?", event)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := db.Find(&deliveries).Error; err != nil {
+		apierror.Internal(c, "Error fetching deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// GetWebhookDelivery returns a specific delivery of a webhook
+func GetWebhookDelivery(c *gin.Context) {
+	var webhook models.Webhook
+	var delivery models.WebhookDelivery
+	if !findWebhook(c, &webhook) || !findWebhookDelivery(c, &webhook, &delivery) {
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhook sends a delivery again as a new delivery with the same
+// event ID and payload, e.g. after the receiving end was fixed
+func RedeliverWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	var delivery models.WebhookDelivery
+	if !findWebhook(c, &webhook) || !findWebhookDelivery(c, &webhook, &delivery) {
+		return
+	}
+	if !webhook.Active {
+		apierror.Conflict(c, "Webhook is disabled; enable it before redelivering")
+		return
+	}
+
+	redelivery, err := webhooks.Redeliver(delivery)
+	if err != nil {
+		apierror.Internal(c, "Error queueing redelivery")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, redelivery)
+}
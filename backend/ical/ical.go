@@ -0,0 +1,138 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateTimeFormat is the UTC form of an iCalendar DATE-TIME value
+const dateTimeFormat = "20060102T150405Z"
+
+// Event is a single VEVENT. UID must stay the same for the lifetime of the
+// event and Sequence must grow whenever it changes, so calendar apps replace
+// their copy instead of adding a new one.
+type Event struct {
+	UID          string
+	Sequence     int
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Categories   []string
+	Start        time.Time
+	End          time.Time
+	Created      time.Time
+	LastModified time.Time
+	Cancelled    bool
+}
+
+// Calendar is a VCALENDAR holding events. Times are written in UTC;
+// Timezone is only a display hint for clients that support it.
+type Calendar struct {
+	Name     string
+	Timezone string
+	Events   []Event
+}
+
+// Bytes renders the calendar as an RFC 5545 document
+func (cal *Calendar) Bytes() []byte {
+	var b bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Avions Club//Website//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+	if cal.Timezone != "" {
+		line("X-WR-TIMEZONE", cal.Timezone)
+	}
+
+	for _, event := range cal.Events {
+		// With METHOD:PUBLISH the stamp is the time the event was last
+		// revised, which also keeps the document stable between requests
+		stamp := event.LastModified
+		if stamp.IsZero() {
+			stamp = event.Created
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("SEQUENCE", fmt.Sprint(event.Sequence))
+		line("DTSTAMP", formatTime(stamp))
+		line("DTSTART", formatTime(event.Start))
+		line("DTEND", formatTime(event.End))
+		if !event.Created.IsZero() {
+			line("CREATED", formatTime(event.Created))
+		}
+		if !event.LastModified.IsZero() {
+			line("LAST-MODIFIED", formatTime(event.LastModified))
+		}
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escape(event.Location))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, 0, len(event.Categories))
+			for _, category := range event.Categories {
+				categories = append(categories, escape(category))
+			}
+			line("CATEGORIES", strings.Join(categories, ","))
+		}
+		if event.Cancelled {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+// escape escapes a TEXT value as required by RFC 5545 section 3.3.11
+func escape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
+
+// writeFolded writes a content line, folding it so no line is longer than
+// 75 octets, without splitting UTF-8 sequences
+func writeFolded(b *bytes.Buffer, line string) {
+	const limit = 75
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer writes each message to an .eml file in Dir instead of sending
+// it, so development setups and tests can inspect outgoing mail
+type FileMailer struct {
+	From string
+	Dir  string
+}
+
+// Send writes a message to a new file in Dir
+func (m FileMailer) Send(msg Message) error {
+	body, err := msg.Bytes(m.From)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_", "<", "", ">", "", " ", "").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), recipient)
+	return os.WriteFile(filepath.Join(m.Dir, name), body, 0o644)
+}
+
+// LogMailer logs messages instead of sending them
+type LogMailer struct {
+	From string
+}
+
+// Send logs the recipient, subject and text of a message
+func (m LogMailer) Send(msg Message) error {
+	if _, err := msg.Bytes(m.From); err != nil {
+		return err
+	}
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
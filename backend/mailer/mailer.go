@@ -0,0 +1,166 @@
+// Package mailer sends email through a pluggable backend: SMTP in
+// production, or files and the log in development and tests.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultFrom is the sender used when MAIL_FROM is not set
+const defaultFrom = "Avions Club <no-reply@localhost>"
+
+// Message is an email to a single recipient. HTML is optional; Text is
+// always sent so every client can show the message.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers such as List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default is the mailer used by the package-level Send
+var Default Mailer = LogMailer{From: defaultFrom}
+
+// Init configures Default from the environment. MAILER selects the backend:
+// "smtp", "file" or "log" (the default).
+func Init() error {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = defaultFrom
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return fmt.Errorf("invalid MAIL_FROM: %v", err)
+	}
+
+	switch backend := os.Getenv("MAILER"); backend {
+	case "", "log":
+		Default = LogMailer{From: from}
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		Default = FileMailer{From: from, Dir: dir}
+	case "smtp":
+		m, err := smtpFromEnv(from)
+		if err != nil {
+			return err
+		}
+		Default = m
+	default:
+		return fmt.Errorf("unknown MAILER %q: must be smtp, file or log", backend)
+	}
+	return nil
+}
+
+// Send delivers a message with the Default mailer
+func Send(msg Message) error {
+	return Default.Send(msg)
+}
+
+// Bytes renders a message as an RFC 5322 email from the given sender
+func (m Message) Bytes(from string) ([]byte, error) {
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %v", m.To, err)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %v", from, err)
+	}
+
+	var buf bytes.Buffer
+	headers := map[string]string{
+		"From":         sender.String(),
+		"To":           m.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   messageID(sender.Address),
+		"MIME-Version": "1.0",
+	}
+	for name, value := range m.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.ContainsAny(headers[name], "\r\n") {
+			return nil, fmt.Errorf("header %s contains a line break", name)
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headers[name])
+	}
+
+	if m.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable writes body with CRLF line endings, encoded as
+// quoted-printable
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-ID in the sender's domain
+func messageID(address string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		domain = address[at+1:]
+	}
+	raw := make([]byte, 12)
+	_, _ = rand.Read(raw)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(raw), domain)
+}
@@ -3,10 +3,19 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"avions-club/backend/analytics"
 	"avions-club/backend/database"
+	"avions-club/backend/events"
+	"avions-club/backend/mailer"
+	"avions-club/backend/newsletter"
 	"avions-club/backend/routes"
 	"avions-club/backend/storage"
+	"avions-club/backend/trash"
+	"avions-club/backend/validation"
+	"avions-club/backend/webhooks"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -27,6 +36,33 @@ func main() {
 	// Initialize database
 	database.InitDB()
 
+	// Purge deleted content once it has outlived the retention period
+	trash.StartPurger(database.DB, time.Hour)
+
+	// Write buffered view counts in batches
+	analytics.Start(database.DB, time.Minute)
+
+	// Configure outgoing mail and send the newsletter digest on schedule
+	if err := mailer.Init(); err != nil {
+		log.Fatal("Failed to configure mailer:", err)
+	}
+	if err := newsletter.Init(); err != nil {
+		log.Fatal("Failed to configure newsletter:", err)
+	}
+	newsletter.StartDigest(database.DB, time.Hour)
+
+	// Send webhook deliveries and retry failed ones
+	webhooks.Start(database.DB, 15*time.Second)
+
+	// Hand stored domain events to their subscribers; subscribers register
+	// above
+	events.Start(database.DB, 2*time.Second)
+
+	// Register request validation rules
+	if err := validation.Register(); err != nil {
+		log.Fatal("Failed to register validation rules:", err)
+	}
+
 	// Debug: Print environment variables
 	log.Println("SUPABASE_URL:", os.Getenv("SUPABASE_URL"))
 	log.Println("SUPABASE_SERVICE_KEY exists:", os.Getenv("SUPABASE_SERVICE_KEY") != "")
@@ -39,6 +75,18 @@ func main() {
 	// Initialize router
 	r := gin.Default()
 
+	// Only trust X-Forwarded-For from our own proxies, so clients cannot pick
+	// the IP address that rate limits and the audit log see
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// Configure CORS
 	config := cors.DefaultConfig()
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
@@ -48,7 +96,8 @@ func main() {
 		config.AllowOrigins = []string{"http://localhost:3000", "http://localhost:5173"}
 	}
 	config.AllowCredentials = true
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", "If-None-Match"}
+	config.ExposeHeaders = []string{"ETag"}
 	r.Use(cors.New(config))
 
 	// Setup routes
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"avions-club/backend/database"
+	"avions-club/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxAuditCapture is how much of a create response is kept to find the ID
+// of the new entity
+const maxAuditCapture = 1 << 20
+
+// auditEntity is the model whose fields are compared before and after a
+// request to a resource, and the relations edited along with it, each with
+// the order that keeps its snapshot stable
+type auditEntity struct {
+	newModel  func() any
+	relations map[string]string
+}
+
+// Relations that requests to a resource replace along with the entity, so
+// that changing e.g. the co-authors of a blog shows up in its changes
+var (
+	memberRelations = map[string]string{
+		"Positions":      "started_at, id",
+		"Contacts":       "position, id",
+		"Certifications": "id",
+	}
+	blogRelations        = map[string]string{"Authors": "position"}
+	eventRelations       = map[string]string{"Projects": "id"}
+	sponsorRelations     = map[string]string{"Projects": "id"}
+	achievementRelations = map[string]string{"Members": "id"}
+	albumRelations       = map[string]string{"Photos": "position, id"}
+)
+
+// auditedEntities maps the resource of a route to what its changes are read
+// from. Resources without a model, such as storage, are recorded without
+// changes.
+var auditedEntities = map[string]auditEntity{
+	"members":             {func() any { return &models.Member{} }, memberRelations},
+	"projects":            {func() any { return &models.Project{} }, nil},
+	"blogs":               {func() any { return &models.Blog{} }, blogRelations},
+	"events":              {func() any { return &models.Event{} }, eventRelations},
+	"flights":             {func() any { return &models.FlightLog{} }, nil},
+	"sponsors":            {func() any { return &models.Sponsor{} }, sponsorRelations},
+	"competitions":        {func() any { return &models.Competition{} }, nil},
+	"achievements":        {func() any { return &models.Achievement{} }, achievementRelations},
+	"albums":              {func() any { return &models.Album{} }, albumRelations},
+	"inventory":           {func() any { return &models.InventoryItem{} }, nil},
+	"inventory/checkouts": {func() any { return &models.InventoryCheckout{} }, nil},
+	"applications":        {func() any { return &models.Application{} }, nil},
+	"comments":            {func() any { return &models.Comment{} }, nil},
+	"contact/messages":    {func() any { return &models.ContactMessage{} }, nil},
+	"webhooks":            {func() any { return &models.Webhook{} }, nil},
+	"outbox":              {func() any { return &models.OutboxEvent{} }, nil},
+	"trash/members":       {func() any { return &models.Member{} }, memberRelations},
+	"trash/projects":      {func() any { return &models.Project{} }, nil},
+	"trash/blogs":         {func() any { return &models.Blog{} }, blogRelations},
+	"trash/events":        {func() any { return &models.Event{} }, eventRelations},
+	"trash/flights":       {func() any { return &models.FlightLog{} }, nil},
+	"trash/inventory":     {func() any { return &models.InventoryItem{} }, nil},
+	"trash/sponsors":      {func() any { return &models.Sponsor{} }, sponsorRelations},
+	"trash/competitions":  {func() any { return &models.Competition{} }, nil},
+	"trash/albums":        {func() any { return &models.Album{} }, albumRelations},
+}
+
+// auditVerbs names the action of each mutating method
+var auditVerbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "update",
+	http.MethodDelete: "delete",
+}
+
+// auditRoute describes what a route acts on: the resource is the static path
+// before the first parameter, the entity ID is the first parameter, and sub
+// is the static path after it, e.g. "photos" for /api/albums/:id/photos.
+type auditRoute struct {
+	resource string
+	params   []string
+	sub      string
+}
+
+// parseAuditRoute splits a route pattern such as /api/albums/:id/photos
+func parseAuditRoute(route string) auditRoute {
+	var parsed auditRoute
+	var before, after []string
+	for _, segment := range strings.Split(strings.TrimPrefix(route, "/api/"), "/") {
+		switch {
+		case strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*"):
+			parsed.params = append(parsed.params, segment[1:])
+		case len(parsed.params) == 0:
+			before = append(before, segment)
+		default:
+			after = append(after, segment)
+		}
+	}
+	parsed.resource = strings.Join(before, "/")
+	parsed.sub = strings.Join(after, ".")
+	return parsed
+}
+
+// action names what a request did: create, update or delete for the entity
+// itself, the sub-resource and verb for nested routes such as
+// "photos.delete", and the command for POST routes such as "restore"
+func (r auditRoute) action(method string) string {
+	verb := auditVerbs[method]
+	switch {
+	case r.sub == "":
+		return verb
+	case method == http.MethodPost:
+		return r.sub
+	default:
+		return r.sub + "." + verb
+	}
+}
+
+// auditRecorder keeps the start of the response so the ID of a created
+// entity can be read from it
+type auditRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditRecorder) Write(b []byte) (int, error) {
+	if w.body.Len() < maxAuditCapture {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditRecorder) WriteString(s string) (int, error) {
+	if w.body.Len() < maxAuditCapture {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// auditSnapshot returns the JSON fields of an entity with its relations, or
+// nil when it does not exist. Fields hidden from the API, such as secrets,
+// are left out.
+func auditSnapshot(entity auditEntity, id string) map[string]any {
+	if entity.newModel == nil {
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil
+	}
+	model := entity.newModel()
+	db := database.DB
+	for relation, order := range entity.relations {
+		db = db.Preload(relation, func(db *gorm.DB) *gorm.DB { return db.Order(order) })
+	}
+	if err := db.First(model, "id = ?", id).Error; err != nil {
+		return nil
+	}
+	raw, err := json.Marshal(model)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// auditDiff returns the fields that differ between two snapshots. The update
+// time is left out since every write changes it.
+func auditDiff(before, after map[string]any) map[string]models.AuditChange {
+	changes := make(map[string]models.AuditChange)
+	for key, value := range before {
+		if !reflect.DeepEqual(value, after[key]) {
+			changes[key] = models.AuditChange{Before: value, After: after[key]}
+		}
+	}
+	for key, value := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = models.AuditChange{After: value}
+		}
+	}
+	delete(changes, "updatedAt")
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// Audit records every mutating request in the audit log: who made it, what
+// it acted on and which fields of that entity changed. Use it after
+// AuthMiddleware. Failing to record is logged and never fails the request.
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := auditVerbs[c.Request.Method]; !ok || database.DB == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		route := parseAuditRoute(c.FullPath())
+		entity, audited := auditedEntities[route.resource]
+		entityID := ""
+		if len(route.params) > 0 {
+			entityID = c.Param(route.params[0])
+		}
+		// Resources without a model, such as storage files, are named by all
+		// their parameters
+		if !audited && len(route.params) > 1 {
+			values := make([]string, len(route.params))
+			for i, param := range route.params {
+				values[i] = c.Param(param)
+			}
+			entityID = strings.Join(values, "/")
+		}
+		before := auditSnapshot(entity, entityID)
+
+		recorder := &auditRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		status := recorder.Status()
+		// A create reports the new ID in its response
+		if entityID == "" && status >= 200 && status < 300 {
+			var created struct {
+				ID       string `json:"id"`
+				Filename string `json:"filename"`
+			}
+			if json.Unmarshal(recorder.body.Bytes(), &created) == nil {
+				entityID = created.ID
+				if entityID == "" {
+					entityID = created.Filename
+				}
+			}
+		}
+		var changes map[string]models.AuditChange
+		if status < 400 {
+			changes = auditDiff(before, auditSnapshot(entity, entityID))
+		}
+
+		actor, session := Actor(c)
+		entry := models.AuditLog{
+			Actor:      actor,
+			SessionID:  session,
+			Action:     route.action(c.Request.Method),
+			Method:     c.Request.Method,
+			Route:      c.FullPath(),
+			Path:       c.Request.URL.Path,
+			EntityType: route.resource,
+			EntityID:   entityID,
+			Status:     status,
+			Changes:    changes,
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err := database.DB.Create(&entry).Error; err != nil {
+			log.Printf("Error recording audit log for %s %s: %v", entry.Method, entry.Path, err)
+		}
+	}
+}
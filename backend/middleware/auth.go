@@ -2,13 +2,16 @@ package middleware
 
 import (
 	"fmt"
-	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"avions-club/backend/apierror"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var jwtKey = []byte(os.Getenv("JWT_SECRET"))
@@ -24,47 +27,35 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			apierror.Unauthorized(c, "Authorization header is required")
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-			c.Abort()
+			apierror.Unauthorized(c, "Invalid token format")
 			return
 		}
 
 		tokenStr := bearerToken[1]
-		claims := &Claims{}
-
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtKey, nil
-		})
+		claims, token, err := parseToken(tokenStr)
 
 		if err != nil {
 			if err == jwt.ErrSignatureInvalid {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token signature"})
+				apierror.Unauthorized(c, "Invalid token signature")
 			} else {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+				apierror.BadRequest(c, "Invalid token")
 			}
-			c.Abort()
 			return
 		}
 
 		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			apierror.Unauthorized(c, "Invalid token")
 			return
 		}
 
 		if !claims.IsAdmin {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-			c.Abort()
+			apierror.Forbidden(c, "Admin access required")
 			return
 		}
 
@@ -73,12 +64,63 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// GenerateToken creates a new JWT token for admin access (expires in 2 hours)
-func GenerateToken() (string, error) {
+// IsAdmin reports whether the request carries a valid admin token. Unlike
+// AuthMiddleware it never rejects the request, so public routes can use it to
+// decide how much to show.
+func IsAdmin(c *gin.Context) bool {
+	bearerToken := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
+		return false
+	}
+
+	claims, token, err := parseToken(bearerToken[1])
+	return err == nil && token.Valid && claims.IsAdmin
+}
+
+// AdminView is IsAdmin for public responses that show more to admins. It
+// marks the response as varying with the Authorization header and private,
+// so shared caches never hand the admin view to other readers.
+func AdminView(c *gin.Context) bool {
+	if !slices.Contains(c.Writer.Header().Values("Vary"), "Authorization") {
+		c.Writer.Header().Add("Vary", "Authorization")
+	}
+	c.Header("Cache-Control", "private")
+	return IsAdmin(c)
+}
+
+// parseToken validates the signature of a JWT and returns its claims
+func parseToken(tokenStr string) (*Claims, *jwt.Token, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return jwtKey, nil
+	})
+	return claims, token, err
+}
+
+// Actor returns who is making an authenticated request: the name given at
+// login and the ID of the login session. Both are empty on public routes.
+func Actor(c *gin.Context) (name, session string) {
+	value, ok := c.Get("claims")
+	if !ok {
+		return "", ""
+	}
+	claims := value.(*Claims)
+	return claims.Subject, claims.ID
+}
+
+// GenerateToken creates a new JWT token for admin access (expires in 2 hours).
+// The admin password is shared, so the token carries the name the admin gave
+// at login and a session ID to tell their actions apart.
+func GenerateToken(actor string) (string, error) {
 	expirationTime := time.Now().Add(2 * time.Hour)
 	claims := &Claims{
 		IsAdmin: true,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   actor,
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
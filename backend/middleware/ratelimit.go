@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"avions-club/backend/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow counts the requests of one client in the current window
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// RateLimit allows each client IP at most limit requests per window and
+// answers 429 Too Many Requests beyond that. Counters live in memory, so each
+// route group using it gets its own budget.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	windows := make(map[string]*rateWindow)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Forget expired windows so the map does not grow without bound
+		if len(windows) > 10000 {
+			for key, w := range windows {
+				if now.After(w.resetAt) {
+					delete(windows, key)
+				}
+			}
+		}
+		w, ok := windows[ip]
+		if !ok || now.After(w.resetAt) {
+			w = &rateWindow{resetAt: now.Add(window)}
+			windows[ip] = w
+		}
+		w.count++
+		count, resetAt := w.count, w.resetAt
+		mu.Unlock()
+
+		if count > limit {
+			retryAfter := int(resetAt.Sub(now).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Respond(c, http.StatusTooManyRequests, apierror.CodeRateLimited,
+				fmt.Sprintf("Too many requests; try again in %d seconds", retryAfter))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Album is a photo gallery, optionally tied to the event or project it covers
+type Album struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description"`
+	EventID     *uuid.UUID     `gorm:"type:uuid;index" json:"eventId"`
+	Event       *Event         `gorm:"foreignKey:EventID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"event,omitempty"`
+	ProjectID   *uuid.UUID     `gorm:"type:uuid;index" json:"projectId"`
+	Project     *Project       `gorm:"foreignKey:ProjectID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"project,omitempty"`
+	CoverURL    string         `gorm:"type:text" json:"coverUrl"`
+	Photos      []Photo        `gorm:"foreignKey:AlbumID;constraint:OnDelete:CASCADE" json:"photos,omitempty"`
+	PhotoCount  int64          `gorm:"-" json:"photoCount"`
+	Version     int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *Album) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// Photo is an uploaded image in an album. Photos are shown by Position.
+type Photo struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	AlbumID        uuid.UUID  `gorm:"type:uuid;not null;index:idx_photos_album_position" json:"albumId"`
+	URL            string     `gorm:"type:text;not null" json:"url"`
+	Caption        string     `gorm:"type:text" json:"caption"`
+	CreditMemberID *uuid.UUID `gorm:"type:uuid;index" json:"creditMemberId"`
+	Credit         *Member    `gorm:"foreignKey:CreditMemberID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"credit,omitempty"`
+	Position       int        `gorm:"not null;index:idx_photos_album_position" json:"position"`
+	CreatedAt      time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *Photo) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
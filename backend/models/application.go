@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Application statuses. Approved and rejected applications are final.
+const (
+	ApplicationStatusPending   = "pending"
+	ApplicationStatusReviewing = "reviewing"
+	ApplicationStatusApproved  = "approved"
+	ApplicationStatusRejected  = "rejected"
+)
+
+// Application is a membership request submitted through the public form
+type Application struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name        string     `gorm:"type:varchar(255);not null" json:"name"`
+	Email       string     `gorm:"type:varchar(255);not null;index" json:"email"`
+	Department  string     `gorm:"type:varchar(255)" json:"department"`
+	YearOfStudy *int       `json:"yearOfStudy"`
+	Skills      []string   `gorm:"type:jsonb;serializer:json" json:"skills"`
+	Motivation  string     `gorm:"type:text;not null" json:"motivation"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewNotes string     `gorm:"type:text" json:"reviewNotes"`
+	ReviewedAt  *time.Time `gorm:"type:timestamp with time zone" json:"reviewedAt"`
+	MemberID    *uuid.UUID `gorm:"type:uuid" json:"memberId"`
+	Member      *Member    `gorm:"foreignKey:MemberID;constraint:OnDelete:SET NULL" json:"member,omitempty"`
+	IPAddress   string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	UserAgent   string     `gorm:"type:text" json:"userAgent"`
+	CreatedAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *Application) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
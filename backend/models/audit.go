@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditChange is the value of one field before and after an admin action.
+// Before is null for new fields and After is null for removed ones.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditLog records one mutating admin request. Actor is the name given at
+// login and SessionID the login it came from. EntityType is the resource of
+// the route, such as "projects", and Changes the fields of that resource the
+// request changed. Rows are never updated or deleted.
+type AuditLog struct {
+	ID         uuid.UUID              `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Actor      string                 `gorm:"type:varchar(100);not null;index" json:"actor"`
+	SessionID  string                 `gorm:"type:varchar(64);index" json:"sessionId"`
+	Action     string                 `gorm:"type:varchar(100);not null;index" json:"action"`
+	Method     string                 `gorm:"type:varchar(10);not null" json:"method"`
+	Route      string                 `gorm:"type:varchar(255);not null" json:"route"`
+	Path       string                 `gorm:"type:text;not null" json:"path"`
+	EntityType string                 `gorm:"type:varchar(100);not null;index:idx_audit_logs_entity,priority:1" json:"entityType"`
+	EntityID   string                 `gorm:"type:varchar(255);index:idx_audit_logs_entity,priority:2" json:"entityId"`
+	Status     int                    `gorm:"not null" json:"status"`
+	Changes    map[string]AuditChange `gorm:"type:jsonb;serializer:json" json:"changes"`
+	IPAddress  string                 `gorm:"type:varchar(64)" json:"ipAddress"`
+	UserAgent  string                 `gorm:"type:text" json:"userAgent"`
+	DurationMs int64                  `gorm:"not null;default:0" json:"durationMs"`
+	CreatedAt  time.Time              `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP;index" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
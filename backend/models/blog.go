@@ -8,15 +8,20 @@ import (
 )
 
 type Blog struct {
-	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
-	Description string         `gorm:"type:text;not null" json:"description"`
-	MarkdownURL string         `gorm:"type:text" json:"markdownUrl"`
-	AuthorID    uuid.UUID      `gorm:"type:uuid;not null" json:"authorId"`
-	Author      Member         `gorm:"foreignKey:AuthorID" json:"author"`
-	CreatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
-	UpdatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Title          string         `gorm:"type:varchar(255);not null" json:"title"`
+	Description    string         `gorm:"type:text;not null" json:"description"`
+	MarkdownURL    string         `gorm:"type:text" json:"markdownUrl"`
+	CoverURL       string         `gorm:"type:text" json:"coverUrl"`
+	Tags           []string       `gorm:"type:jsonb;serializer:json" json:"tags"`
+	AuthorID       uuid.UUID      `gorm:"type:uuid;not null" json:"authorId"`
+	Author         Member         `gorm:"foreignKey:AuthorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"author"`
+	Authors        []BlogAuthor   `gorm:"foreignKey:BlogID;constraint:OnDelete:CASCADE" json:"authors"`
+	CommentsClosed bool           `gorm:"not null;default:false" json:"commentsClosed"`
+	Version        int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt      time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// BlogAuthor links a blog to one of the members who wrote it. Position orders
+// the byline; the primary author is always at position 0.
+type BlogAuthor struct {
+	BlogID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	MemberID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"memberId"`
+	Position int       `gorm:"not null;default:0" json:"position"`
+	Member   Member    `gorm:"foreignKey:MemberID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"member"`
+}
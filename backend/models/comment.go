@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Comment moderation statuses. Only approved comments are shown publicly.
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+	CommentStatusSpam     = "spam"
+)
+
+// Comment is a reader's response to a blog. Comments are threaded one level
+// deep: a reply's ParentID points at a top-level comment. Guests give a name
+// and email; comments posted by admins on behalf of a member link MemberID.
+type Comment struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BlogID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"blogId"`
+	Blog        *Blog      `gorm:"foreignKey:BlogID;constraint:OnDelete:CASCADE" json:"blog,omitempty"`
+	ParentID    *uuid.UUID `gorm:"type:uuid;index" json:"parentId"`
+	Replies     []Comment  `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE" json:"replies,omitempty"`
+	MemberID    *uuid.UUID `gorm:"type:uuid;index" json:"memberId"`
+	Member      *Member    `gorm:"foreignKey:MemberID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"member,omitempty"`
+	Name        string     `gorm:"type:varchar(255);not null" json:"name"`
+	Email       string     `gorm:"type:varchar(255);index" json:"email,omitempty"`
+	Body        string     `gorm:"type:text;not null" json:"body"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ModeratedAt *time.Time `gorm:"type:timestamp with time zone" json:"moderatedAt,omitempty"`
+	IPAddress   string     `gorm:"type:varchar(64);index" json:"ipAddress,omitempty"`
+	UserAgent   string     `gorm:"type:text" json:"userAgent,omitempty"`
+	CreatedAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Competition categories
+const (
+	CompetitionCategoryAeroDesign   = "aero_design"
+	CompetitionCategoryDroneRacing  = "drone_racing"
+	CompetitionCategoryUAVChallenge = "uav_challenge"
+	CompetitionCategoryOther        = "other"
+)
+
+// Competition is an edition of a contest the club took part in, such as SAE
+// Aero Design in a given year
+type Competition struct {
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Organizer    string         `gorm:"type:varchar(255)" json:"organizer"`
+	Category     string         `gorm:"type:varchar(20);not null;default:'other';index" json:"category"`
+	Year         int            `gorm:"not null;index" json:"year"`
+	Location     string         `gorm:"type:varchar(255)" json:"location"`
+	Description  string         `gorm:"type:text" json:"description"`
+	WebsiteURL   string         `gorm:"type:text" json:"websiteUrl"`
+	ImageURL     string         `gorm:"type:text" json:"imageUrl"`
+	Achievements []Achievement  `gorm:"foreignKey:CompetitionID;constraint:OnDelete:CASCADE" json:"achievements,omitempty"`
+	Version      int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt    time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *Competition) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// Achievement is a result the club earned at a competition: a placement, an
+// award or both, credited to a team, the project it entered and the members
+// who took part. EvidenceURLs point at uploaded photos or certificates.
+type Achievement struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CompetitionID uuid.UUID    `gorm:"type:uuid;not null;index" json:"competitionId"`
+	Competition   *Competition `gorm:"foreignKey:CompetitionID" json:"competition,omitempty"`
+	ProjectID     *uuid.UUID   `gorm:"type:uuid;index" json:"projectId"`
+	Project       *Project     `gorm:"foreignKey:ProjectID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"project,omitempty"`
+	Team          string       `gorm:"type:varchar(255)" json:"team"`
+	Placement     *int         `json:"placement"`
+	Award         string       `gorm:"type:varchar(255)" json:"award"`
+	Description   string       `gorm:"type:text" json:"description"`
+	EvidenceURLs  []string     `gorm:"type:jsonb;serializer:json" json:"evidenceUrls"`
+	Members       []Member     `gorm:"many2many:achievement_members;constraint:OnDelete:CASCADE" json:"members,omitempty"`
+	Version       int          `gorm:"not null;default:1" json:"-"`
+	CreatedAt     time.Time    `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time    `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *Achievement) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
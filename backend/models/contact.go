@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Contact message statuses in the admin inbox
+const (
+	ContactStatusNew      = "new"
+	ContactStatusRead     = "read"
+	ContactStatusArchived = "archived"
+)
+
+// ContactMessage is a message sent through the public contact form.
+// ForwardedAt is set once it has been emailed to the club.
+type ContactMessage struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name         string     `gorm:"type:varchar(255);not null" json:"name"`
+	Email        string     `gorm:"type:varchar(255);not null;index" json:"email"`
+	Organization string     `gorm:"type:varchar(255)" json:"organization"`
+	Topic        string     `gorm:"type:varchar(20);not null;default:'general';index" json:"topic"`
+	Subject      string     `gorm:"type:varchar(255);not null" json:"subject"`
+	Message      string     `gorm:"type:text;not null" json:"message"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'new';index" json:"status"`
+	ForwardedAt  *time.Time `gorm:"type:timestamp with time zone" json:"forwardedAt"`
+	IPAddress    string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	UserAgent    string     `gorm:"type:text" json:"userAgent"`
+	CreatedAt    time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (m *ContactMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
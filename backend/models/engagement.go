@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Content types that record views and reactions
+const (
+	ContentTypeBlog    = "blog"
+	ContentTypeProject = "project"
+)
+
+// Reaction kinds readers can leave
+const (
+	ReactionLike   = "like"
+	ReactionLove   = "love"
+	ReactionClap   = "clap"
+	ReactionRocket = "rocket"
+)
+
+// ContentView is the number of distinct visitors who viewed a blog or a
+// project on one day
+type ContentView struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ContentType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_content_views_day" json:"contentType"`
+	ContentID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_content_views_day" json:"contentId"`
+	Day         time.Time `gorm:"type:date;not null;uniqueIndex:idx_content_views_day;index" json:"day"`
+	Views       int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (v *ContentView) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// Reaction is one visitor's reaction to a blog or a project. Visitors are
+// identified by a hash of their IP address and user agent, and can leave each
+// kind of reaction once.
+type Reaction struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ContentType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reactions_visitor" json:"contentType"`
+	ContentID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reactions_visitor" json:"contentId"`
+	Kind        string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reactions_visitor" json:"kind"`
+	VisitorHash string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_reactions_visitor" json:"-"`
+	CreatedAt   time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *Reaction) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
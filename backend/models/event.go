@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Event kinds
+const (
+	EventKindFlightTest  = "flight_test"
+	EventKindWorkshop    = "workshop"
+	EventKindCompetition = "competition"
+	EventKindMeeting     = "meeting"
+	EventKindOther       = "other"
+)
+
+// Event statuses. Cancelled events stay listed so calendars can drop them.
+const (
+	EventStatusScheduled = "scheduled"
+	EventStatusCancelled = "cancelled"
+)
+
+// Event is something the club runs, such as a flight test or a workshop.
+// Events without a Capacity take any number of RSVPs.
+type Event struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description"`
+	Kind        string         `gorm:"type:varchar(20);not null;default:'other';index" json:"kind"`
+	Location    string         `gorm:"type:varchar(255)" json:"location"`
+	Status      string         `gorm:"type:varchar(20);not null;default:'scheduled'" json:"status"`
+	Tags        []string       `gorm:"type:jsonb;serializer:json" json:"tags"`
+	ImageURL    string         `gorm:"type:text" json:"imageUrl"`
+	StartsAt    time.Time      `gorm:"type:timestamp with time zone;not null;index" json:"startsAt"`
+	EndsAt      time.Time      `gorm:"type:timestamp with time zone;not null" json:"endsAt"`
+	Capacity    *int           `json:"capacity"`
+	Projects    []Project      `gorm:"many2many:event_projects;constraint:OnDelete:CASCADE" json:"projects"`
+	Going       int64          `gorm:"-" json:"going"`
+	Waitlisted  int64          `gorm:"-" json:"waitlisted"`
+	Version     int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// RSVP statuses. Waitlisted RSVPs move up, oldest first, when a spot frees up.
+const (
+	RSVPStatusGoing      = "going"
+	RSVPStatusWaitlisted = "waitlisted"
+	RSVPStatusCancelled  = "cancelled"
+)
+
+// EventRSVP is someone's reply to an event. Only a hash of the token that
+// lets them cancel it is stored.
+type EventRSVP struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	EventID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_event_rsvps_event_email" json:"eventId"`
+	Event       Event      `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"-"`
+	Name        string     `gorm:"type:varchar(255);not null" json:"name"`
+	Email       string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_event_rsvps_event_email" json:"email"`
+	MemberID    *uuid.UUID `gorm:"type:uuid;index" json:"memberId"`
+	Member      *Member    `gorm:"foreignKey:MemberID;constraint:OnDelete:SET NULL" json:"-"`
+	Status      string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	TokenHash   string     `gorm:"type:varchar(64);not null;index" json:"-"`
+	CheckedInAt *time.Time `gorm:"type:timestamp with time zone" json:"checkedInAt"`
+	CreatedAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *EventRSVP) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Flight outcomes
+const (
+	FlightOutcomeSuccess = "success"
+	FlightOutcomePartial = "partial"
+	FlightOutcomeAborted = "aborted"
+	FlightOutcomeCrash   = "crash"
+)
+
+// FlightLog records a single flight of a club aircraft or drone for a
+// project. Logs are removed with their project when it is purged, while a
+// pilot with logged flights, including flights in the trash, cannot be
+// purged.
+type FlightLog struct {
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ProjectID       uuid.UUID      `gorm:"type:uuid;not null;index" json:"projectId"`
+	Project         *Project       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"project,omitempty"`
+	PilotID         uuid.UUID      `gorm:"type:uuid;not null;index" json:"pilotId"`
+	Pilot           *Member        `gorm:"foreignKey:PilotID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"pilot,omitempty"`
+	Aircraft        string         `gorm:"type:varchar(255);not null;index" json:"aircraft"`
+	FlownAt         time.Time      `gorm:"type:timestamp with time zone;not null;index" json:"flownAt"`
+	DurationSeconds int            `gorm:"not null" json:"durationSeconds"`
+	Location        string         `gorm:"type:varchar(255)" json:"location"`
+	Conditions      string         `gorm:"type:text" json:"conditions"`
+	Outcome         string         `gorm:"type:varchar(20);not null;index" json:"outcome"`
+	Notes           string         `gorm:"type:text" json:"notes"`
+	Version         int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt       time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt       time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (f *FlightLog) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
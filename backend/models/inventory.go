@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Inventory categories
+const (
+	InventoryCategoryBattery    = "battery"
+	InventoryCategoryMotor      = "motor"
+	InventoryCategoryRadio      = "radio"
+	InventoryCategoryAirframe   = "airframe"
+	InventoryCategoryElectronic = "electronics"
+	InventoryCategoryTool       = "tool"
+	InventoryCategoryPart       = "part"
+	InventoryCategoryOther      = "other"
+)
+
+// Inventory conditions
+const (
+	InventoryConditionNew     = "new"
+	InventoryConditionGood    = "good"
+	InventoryConditionFair    = "fair"
+	InventoryConditionDamaged = "damaged"
+	InventoryConditionRetired = "retired"
+)
+
+// InventoryItem is a kind of equipment or part the club owns. Quantity counts
+// the units the club has. Units of regular items are lent out and come back;
+// consumables such as props and connectors are used up when handed out, and
+// are reported as low on stock once Quantity drops to LowStockThreshold.
+type InventoryItem struct {
+	ID                uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name              string         `gorm:"type:varchar(255);not null" json:"name"`
+	Description       string         `gorm:"type:text" json:"description"`
+	Category          string         `gorm:"type:varchar(20);not null;default:'other';index" json:"category"`
+	Consumable        bool           `gorm:"not null;default:false" json:"consumable"`
+	Quantity          int            `gorm:"not null;default:0" json:"quantity"`
+	LowStockThreshold *int           `json:"lowStockThreshold"`
+	Condition         string         `gorm:"type:varchar(20);not null;default:'good'" json:"condition"`
+	Location          string         `gorm:"type:varchar(255)" json:"location"`
+	ImageURL          string         `gorm:"type:text" json:"imageUrl"`
+	CheckedOut        int64          `gorm:"-" json:"checkedOut"`
+	Available         int64          `gorm:"-" json:"available"`
+	Version           int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt         time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt         time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (i *InventoryItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// InventoryCheckout records units of an item handed to a member, optionally
+// for a project. Checkouts of consumables are closed when they are made;
+// others stay open until the units are returned.
+type InventoryCheckout struct {
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ItemID          uuid.UUID      `gorm:"type:uuid;not null;index" json:"itemId"`
+	Item            *InventoryItem `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+	MemberID        uuid.UUID      `gorm:"type:uuid;not null;index" json:"memberId"`
+	Member          *Member        `gorm:"foreignKey:MemberID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"member,omitempty"`
+	ProjectID       *uuid.UUID     `gorm:"type:uuid;index" json:"projectId"`
+	Project         *Project       `gorm:"foreignKey:ProjectID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"project,omitempty"`
+	Quantity        int            `gorm:"not null" json:"quantity"`
+	Consumed        bool           `gorm:"not null;default:false" json:"consumed"`
+	Notes           string         `gorm:"type:text" json:"notes"`
+	CheckedOutAt    time.Time      `gorm:"type:timestamp with time zone;not null" json:"checkedOutAt"`
+	DueAt           *time.Time     `gorm:"type:timestamp with time zone;index" json:"dueAt"`
+	ReturnedAt      *time.Time     `gorm:"type:timestamp with time zone;index" json:"returnedAt"`
+	ReturnCondition string         `gorm:"type:varchar(20)" json:"returnCondition,omitempty"`
+	Overdue         bool           `gorm:"-" json:"overdue"`
+	CreatedAt       time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt       time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (ic *InventoryCheckout) BeforeCreate(tx *gorm.DB) error {
+	if ic.ID == uuid.Nil {
+		ic.ID = uuid.New()
+	}
+	return nil
+}
+
+// AfterFind flags open checkouts that are past their due date
+func (ic *InventoryCheckout) AfterFind(tx *gorm.DB) error {
+	ic.Overdue = ic.ReturnedAt == nil && !ic.Consumed && ic.DueAt != nil && ic.DueAt.Before(time.Now())
+	return nil
+}
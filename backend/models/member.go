@@ -7,15 +7,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// Membership statuses. Only active and honorary members are listed on the
+// team page; alumni have their own listing.
+const (
+	MemberStatusActive   = "active"
+	MemberStatusAlumni   = "alumni"
+	MemberStatusHonorary = "honorary"
+)
+
 type Member struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Name      string         `gorm:"type:varchar(255);not null" json:"name"`
-	Position  string         `gorm:"type:varchar(255);not null" json:"position"`
-	ImageURL  string         `gorm:"type:text" json:"imageUrl"`
-	JoinedAt  time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"joinedAt"`
-	CreatedAt time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
-	UpdatedAt time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uuid.UUID             `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name           string                `gorm:"type:varchar(255);not null" json:"name"`
+	Position       string                `gorm:"type:varchar(255);not null" json:"position"`
+	ImageURL       string                `gorm:"type:text" json:"imageUrl"`
+	Bio            string                `gorm:"type:text" json:"bio"`
+	Department     string                `gorm:"type:varchar(255)" json:"department"`
+	YearOfStudy    *int                  `json:"yearOfStudy"`
+	Skills         []string              `gorm:"type:jsonb;serializer:json" json:"skills"`
+	Status         string                `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	JoinedAt       time.Time             `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"joinedAt"`
+	LeftAt         *time.Time            `gorm:"type:timestamp with time zone" json:"leftAt"`
+	GraduationYear *int                  `gorm:"index" json:"graduationYear"`
+	Positions      []MemberPosition      `gorm:"foreignKey:MemberID;constraint:OnDelete:CASCADE" json:"positions,omitempty"`
+	Contacts       []MemberContact       `gorm:"foreignKey:MemberID;constraint:OnDelete:CASCADE" json:"contacts,omitempty"`
+	Certifications []MemberCertification `gorm:"foreignKey:MemberID;constraint:OnDelete:CASCADE" json:"certifications,omitempty"`
+	Achievements   []Achievement         `gorm:"many2many:achievement_members;constraint:OnDelete:CASCADE" json:"achievements,omitempty"`
+	Version        int                   `gorm:"not null;default:1" json:"-"`
+	CreatedAt      time.Time             `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time             `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt        `gorm:"index" json:"-"`
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
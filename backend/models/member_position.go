@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemberPosition is one term a member held a position for. The current
+// position has no EndedAt.
+type MemberPosition struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	MemberID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"memberId"`
+	Title     string     `gorm:"type:varchar(255);not null" json:"title"`
+	StartedAt time.Time  `gorm:"type:timestamp with time zone;not null" json:"startedAt"`
+	EndedAt   *time.Time `gorm:"type:timestamp with time zone" json:"endedAt"`
+	CreatedAt time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *MemberPosition) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Contact visibilities. Private contacts are only returned to admins.
+const (
+	ContactVisibilityPublic  = "public"
+	ContactVisibilityPrivate = "private"
+)
+
+// MemberContact is one way of reaching a member, such as an email address or
+// a GitHub profile, shown publicly or kept for admins only
+type MemberContact struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"-"`
+	MemberID   uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Kind       string    `gorm:"type:varchar(20);not null" json:"kind"`
+	Value      string    `gorm:"type:varchar(512);not null" json:"value"`
+	Visibility string    `gorm:"type:varchar(10);not null;default:'public'" json:"visibility"`
+	Position   int       `gorm:"not null;default:0" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (mc *MemberContact) BeforeCreate(tx *gorm.DB) error {
+	if mc.ID == uuid.Nil {
+		mc.ID = uuid.New()
+	}
+	return nil
+}
+
+// MemberCertification is a qualification held by a member, such as a drone
+// pilot licence. Certifications without ExpiresAt never expire.
+type MemberCertification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	MemberID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"memberId"`
+	Name      string     `gorm:"type:varchar(255);not null" json:"name"`
+	Issuer    string     `gorm:"type:varchar(255)" json:"issuer"`
+	IssuedAt  *time.Time `gorm:"type:timestamp with time zone" json:"issuedAt"`
+	ExpiresAt *time.Time `gorm:"type:timestamp with time zone;index" json:"expiresAt"`
+	Expired   bool       `gorm:"-" json:"expired"`
+	CreatedAt time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (mc *MemberCertification) BeforeCreate(tx *gorm.DB) error {
+	if mc.ID == uuid.Nil {
+		mc.ID = uuid.New()
+	}
+	return nil
+}
+
+// AfterFind flags certifications that are past their expiry date
+func (mc *MemberCertification) AfterFind(tx *gorm.DB) error {
+	mc.Expired = mc.ExpiresAt != nil && mc.ExpiresAt.Before(time.Now())
+	return nil
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Subscriber statuses. Only confirmed subscribers receive the digest.
+const (
+	SubscriberStatusPending      = "pending"
+	SubscriberStatusConfirmed    = "confirmed"
+	SubscriberStatusUnsubscribed = "unsubscribed"
+)
+
+// Subscriber is an email address signed up for the newsletter. Signups are
+// confirmed through a link sent to the address; only a hash of that token is
+// stored. The unsubscribe token is kept as is, since every digest links it.
+type Subscriber struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Email            string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"email"`
+	Name             string     `gorm:"type:varchar(255)" json:"name"`
+	Status           string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ConfirmTokenHash string     `gorm:"type:varchar(64);index" json:"-"`
+	ConfirmSentAt    *time.Time `gorm:"type:timestamp with time zone" json:"confirmSentAt"`
+	ConfirmedAt      *time.Time `gorm:"type:timestamp with time zone" json:"confirmedAt"`
+	UnsubscribeToken string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	UnsubscribedAt   *time.Time `gorm:"type:timestamp with time zone" json:"unsubscribedAt"`
+	LastDigestID     *uuid.UUID `gorm:"type:uuid;index" json:"-"`
+	IPAddress        string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	CreatedAt        time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt        time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (s *Subscriber) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// DigestRun records a newsletter digest: the period whose blogs it covered
+// and how many subscribers it reached. The next digest starts where the last
+// one ended. A run is recorded before it is sent and stays InProgress until
+// every subscriber has been mailed, so an interrupted run is resumed.
+type DigestRun struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PeriodStart time.Time `gorm:"type:timestamp with time zone;not null" json:"periodStart"`
+	PeriodEnd   time.Time `gorm:"type:timestamp with time zone;not null;index" json:"periodEnd"`
+	Blogs       int       `gorm:"not null" json:"blogs"`
+	Events      int       `gorm:"not null" json:"events"`
+	Recipients  int       `gorm:"not null" json:"recipients"`
+	Failures    int       `gorm:"not null" json:"failures"`
+	InProgress  bool      `gorm:"not null;default:false" json:"inProgress"`
+	CreatedAt   time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (d *DigestRun) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outbox event statuses
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusProcessed = "processed"
+	OutboxStatusFailed    = "failed"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes and handed to subscribers afterwards. Completed lists
+// the subscribers that already handled it, so a retry only reaches the ones
+// that failed.
+type OutboxEvent struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name          string          `gorm:"type:varchar(100);not null;index" json:"name"`
+	Payload       json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"payload"`
+	Status        string          `gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_events_due,priority:1" json:"status"`
+	NextAttemptAt time.Time       `gorm:"type:timestamp with time zone;not null;index:idx_outbox_events_due,priority:2" json:"nextAttemptAt"`
+	Attempts      int             `gorm:"not null;default:0" json:"attempts"`
+	Completed     []string        `gorm:"type:jsonb;serializer:json;not null" json:"completed"`
+	LastError     string          `gorm:"type:text" json:"lastError"`
+	ProcessedAt   *time.Time      `gorm:"type:timestamp with time zone" json:"processedAt"`
+	CreatedAt     time.Time       `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP;index" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -13,6 +13,8 @@ type Project struct {
 	Description string         `gorm:"type:text;not null" json:"description"`
 	MarkdownURL string         `gorm:"type:text" json:"markdownUrl"`
 	ImageURL    string         `gorm:"type:text" json:"imageUrl"`
+	Telemetry   []TelemetryLog `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE" json:"telemetry,omitempty"`
+	Version     int            `gorm:"not null;default:1" json:"-"`
 	CreatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
 	UpdatedAt   time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sponsor tiers, from the most to the least prominent
+const (
+	SponsorTierPlatinum = "platinum"
+	SponsorTierGold     = "gold"
+	SponsorTierSilver   = "silver"
+	SponsorTierBronze   = "bronze"
+	SponsorTierPartner  = "partner"
+)
+
+// Sponsor is an organisation supporting the club. It is shown on the site
+// from StartsOn until EndsOn, or indefinitely when EndsOn is empty.
+type Sponsor struct {
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Tier         string         `gorm:"type:varchar(20);not null;index" json:"tier"`
+	Description  string         `gorm:"type:text" json:"description"`
+	LogoURL      string         `gorm:"type:text" json:"logoUrl"`
+	WebsiteURL   string         `gorm:"type:text" json:"websiteUrl"`
+	StartsOn     time.Time      `gorm:"type:date;not null" json:"startsOn"`
+	EndsOn       *time.Time     `gorm:"type:date" json:"endsOn"`
+	DisplayOrder int            `gorm:"not null;default:0" json:"displayOrder"`
+	Projects     []Project      `gorm:"many2many:sponsor_projects;constraint:OnDelete:CASCADE" json:"projects"`
+	Active       bool           `gorm:"-" json:"active"`
+	Version      int            `gorm:"not null;default:1" json:"-"`
+	CreatedAt    time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (s *Sponsor) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// AfterFind flags sponsors whose date range includes today
+func (s *Sponsor) AfterFind(tx *gorm.DB) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	s.Active = !s.StartsOn.After(today) && (s.EndsOn == nil || !s.EndsOn.Before(today))
+	return nil
+}
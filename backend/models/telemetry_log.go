@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"avions-club/backend/telemetry"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Telemetry log formats
+const (
+	TelemetryFormatTLog = "tlog"
+	TelemetryFormatCSV  = "csv"
+)
+
+// TelemetryLog is the summary of a flight-controller log uploaded for a
+// project. Only the summary is kept, not the uploaded file.
+type TelemetryLog struct {
+	ID        uuid.UUID         `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ProjectID uuid.UUID         `gorm:"type:uuid;not null;index" json:"projectId"`
+	Filename  string            `gorm:"type:varchar(255);not null" json:"filename"`
+	Format    string            `gorm:"type:varchar(10);not null" json:"format"`
+	SizeBytes int64             `gorm:"not null" json:"sizeBytes"`
+	Summary   telemetry.Summary `gorm:"type:jsonb;serializer:json;not null" json:"summary"`
+	CreatedAt time.Time         `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (t *TelemetryLog) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// Trashable is implemented by models that are soft deleted into the trash
+// before being purged for good
+type Trashable interface {
+	// TrashedAt returns when the row was soft deleted
+	TrashedAt() time.Time
+	// FileURLs lists the storage objects the row points at. Purging removes
+	// the ones no other row, in the trash or not, still points at.
+	FileURLs() []string
+}
+
+func (m *Member) TrashedAt() time.Time { return m.DeletedAt.Time }
+
+func (m *Member) FileURLs() []string { return []string{m.ImageURL} }
+
+func (p *Project) TrashedAt() time.Time { return p.DeletedAt.Time }
+
+func (p *Project) FileURLs() []string { return []string{p.ImageURL, p.MarkdownURL} }
+
+func (b *Blog) TrashedAt() time.Time { return b.DeletedAt.Time }
+
+// FileURLs includes the cover, which is often an upload shared with another
+// blog or an album; purging keeps it while anything else uses it
+func (b *Blog) FileURLs() []string { return []string{b.CoverURL, b.MarkdownURL} }
+
+func (e *Event) TrashedAt() time.Time { return e.DeletedAt.Time }
+
+func (e *Event) FileURLs() []string { return []string{e.ImageURL} }
+
+func (f *FlightLog) TrashedAt() time.Time { return f.DeletedAt.Time }
+
+func (f *FlightLog) FileURLs() []string { return nil }
+
+func (i *InventoryItem) TrashedAt() time.Time { return i.DeletedAt.Time }
+
+func (i *InventoryItem) FileURLs() []string { return []string{i.ImageURL} }
+
+func (s *Sponsor) TrashedAt() time.Time { return s.DeletedAt.Time }
+
+func (s *Sponsor) FileURLs() []string { return []string{s.LogoURL} }
+
+func (c *Competition) TrashedAt() time.Time { return c.DeletedAt.Time }
+
+// FileURLs includes the evidence of the achievements, when they are loaded
+func (c *Competition) FileURLs() []string {
+	urls := []string{c.ImageURL}
+	for _, achievement := range c.Achievements {
+		urls = append(urls, achievement.EvidenceURLs...)
+	}
+	return urls
+}
+
+func (a *Album) TrashedAt() time.Time { return a.DeletedAt.Time }
+
+// FileURLs includes the photos, when they are loaded
+func (a *Album) FileURLs() []string {
+	urls := []string{a.CoverURL}
+	for _, photo := range a.Photos {
+		urls = append(urls, photo.URL)
+	}
+	return urls
+}
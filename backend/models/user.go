@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// User is a personal account linked to a member. Accounts start as
+// invitations: only a hash of the invite token is stored, and ActivatedAt is
+// set once the member has claimed the account.
+type User struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Email           string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"email"`
+	MemberID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"memberId"`
+	Member          Member     `gorm:"foreignKey:MemberID;constraint:OnDelete:CASCADE" json:"-"`
+	InviteTokenHash string     `gorm:"type:varchar(64)" json:"-"`
+	ActivatedAt     *time.Time `gorm:"type:timestamp with time zone" json:"activatedAt"`
+	CreatedAt       time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt       time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook payload formats
+const (
+	WebhookFormatJSON    = "json"
+	WebhookFormatDiscord = "discord"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook is an outside URL notified when content changes. Events lists the
+// event names it receives, or "*" for all of them. Secret signs every
+// delivery and is only shown when the webhook is created.
+type Webhook struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	URL       string    `gorm:"type:text;not null" json:"url"`
+	Format    string    `gorm:"type:varchar(20);not null;default:'json'" json:"format"`
+	Events    []string  `gorm:"type:jsonb;serializer:json;not null" json:"events"`
+	Secret    string    `gorm:"type:varchar(128);not null" json:"-"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	Version   int       `gorm:"not null;default:1" json:"-"`
+	CreatedAt time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to a webhook.
+// Failed attempts are retried at NextAttemptAt until the delivery succeeds
+// or runs out of attempts. RedeliveryOf links a manual redelivery to the
+// delivery it repeats; apart from those, an event is delivered to a webhook
+// once.
+type WebhookDelivery struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	WebhookID      uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_once,where:redelivery_of IS NULL" json:"webhookId"`
+	Webhook        *Webhook        `gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE" json:"-"`
+	EventID        uuid.UUID       `gorm:"type:uuid;not null;index;uniqueIndex:idx_webhook_deliveries_once" json:"eventId"`
+	Event          string          `gorm:"type:varchar(100);not null;index" json:"event"`
+	Payload        json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"payload"`
+	Status         string          `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Attempts       int             `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time      `gorm:"type:timestamp with time zone;index" json:"nextAttemptAt"`
+	LastAttemptAt  *time.Time      `gorm:"type:timestamp with time zone" json:"lastAttemptAt"`
+	ResponseStatus *int            `json:"responseStatus"`
+	ResponseBody   string          `gorm:"type:text" json:"responseBody"`
+	Error          string          `gorm:"type:text" json:"error"`
+	DurationMs     int64           `gorm:"not null;default:0" json:"durationMs"`
+	RedeliveryOf   *uuid.UUID      `gorm:"type:uuid" json:"redeliveryOf"`
+	CreatedAt      time.Time       `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time       `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+// Package newsletter composes the emails sent to newsletter subscribers: the
+// signup confirmation and the periodic digest of new blogs and upcoming
+// events.
+package newsletter
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"avions-club/backend/mailer"
+	"avions-club/backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultIntervalDays is how often the digest goes out when
+// DIGEST_INTERVAL_DAYS is not set
+const defaultIntervalDays = 7
+
+// upcomingWindow is how far ahead the digest lists events
+const upcomingWindow = 14 * 24 * time.Hour
+
+// digestBatch is how many subscribers are loaded at a time while sending
+const digestBatch = 100
+
+// ConfirmTokenLifetime is how long a confirmation link stays valid
+const ConfirmTokenLifetime = 48 * time.Hour
+
+// sending serialises digests so the job and a manual run never overlap
+var sending sync.Mutex
+
+// Interval returns how often the digest is sent
+func Interval() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("DIGEST_INTERVAL_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultIntervalDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ErrDisabled is returned when the newsletter is not configured
+var ErrDisabled = errors.New("the newsletter is disabled because SITE_URL is not set")
+
+// enabled is set by Init when SITE_URL is configured
+var enabled bool
+
+// Init checks SITE_URL, which the links in emails are built from. Without it
+// the newsletter is disabled, since confirmation and unsubscribe links would
+// not work; a SITE_URL that is not an absolute http or https URL is an error.
+func Init() error {
+	raw := os.Getenv("SITE_URL")
+	if raw == "" {
+		enabled = false
+		log.Print("SITE_URL is not set; newsletter signups and digests are disabled")
+		return nil
+	}
+	site, err := url.Parse(raw)
+	if err != nil || (site.Scheme != "http" && site.Scheme != "https") || site.Host == "" {
+		return errors.New("SITE_URL must be the absolute URL of the website")
+	}
+	enabled = true
+	return nil
+}
+
+// Enabled reports whether the newsletter can send mail
+func Enabled() bool {
+	return enabled
+}
+
+// SiteLink returns the website URL of a path, or the path itself when
+// SITE_URL is not set
+func SiteLink(path string) string {
+	return strings.TrimRight(os.Getenv("SITE_URL"), "/") + path
+}
+
+// location returns the club's time zone for dates shown in emails
+func location() *time.Location {
+	if name := os.Getenv("CLUB_TIMEZONE"); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+var confirmText = template.Must(template.New("confirm").Parse(`Hi{{if .Name}} {{.Name}}{{end}},
+
+Please confirm that you want to receive the Avions Club newsletter:
+
+{{.Link}}
+
+The link is valid for 48 hours. If you did not sign up, ignore this email
+and you will not hear from us again.
+`))
+
+// ConfirmationMessage returns the email asking a new subscriber to confirm
+// their address
+func ConfirmationMessage(subscriber models.Subscriber, token string) mailer.Message {
+	var text bytes.Buffer
+	_ = confirmText.Execute(&text, map[string]string{
+		"Name": subscriber.Name,
+		"Link": SiteLink("/newsletter/confirm/" + token),
+	})
+	return mailer.Message{
+		To:      subscriber.Email,
+		Subject: "Confirm your Avions Club newsletter subscription",
+		Text:    text.String(),
+	}
+}
+
+// digestItem is a blog or event as listed in a digest
+type digestItem struct {
+	Title   string
+	Summary string
+	Meta    string
+	Link    string
+}
+
+// digestData is what the digest templates render
+type digestData struct {
+	Name        string
+	Blogs       []digestItem
+	Events      []digestItem
+	Unsubscribe string
+}
+
+var digestText = template.Must(template.New("digest").Parse(`Hi{{if .Name}} {{.Name}}{{end}},
+
+Here is what is new at Avions Club.
+{{if .Blogs}}
+NEW ON THE BLOG
+{{range .Blogs}}
+* {{.Title}} ({{.Meta}})
+  {{.Summary}}
+  {{.Link}}
+{{end}}{{end}}{{if .Events}}
+COMING UP
+{{range .Events}}
+* {{.Title}} - {{.Meta}}
+  {{.Link}}
+{{end}}{{end}}
+--
+Unsubscribe: {{.Unsubscribe}}
+`))
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+<p>Hi{{if .Name}} {{.Name}}{{end}},</p>
+<p>Here is what is new at Avions Club.</p>
+{{if .Blogs}}<h2>New on the blog</h2>
+{{range .Blogs}}<p><a href="{{.Link}}"><strong>{{.Title}}</strong></a><br>
+<small>{{.Meta}}</small><br>{{.Summary}}</p>
+{{end}}{{end}}{{if .Events}}<h2>Coming up</h2>
+<ul>{{range .Events}}<li><a href="{{.Link}}">{{.Title}}</a> - {{.Meta}}</li>{{end}}</ul>
+{{end}}<hr>
+<p><small><a href="{{.Unsubscribe}}">Unsubscribe</a></small></p>
+</body></html>
+`))
+
+// Digest is the content of one digest, sent to every confirmed subscriber
+type Digest struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Blogs       []models.Blog
+	Events      []models.Event
+}
+
+// Empty reports whether the digest has nothing to say
+func (d *Digest) Empty() bool {
+	return len(d.Blogs) == 0 && len(d.Events) == 0
+}
+
+// BuildDigest collects the blogs created since the last digest, or over the
+// last interval for the first one, and the events starting within the next
+// two weeks
+func BuildDigest(db *gorm.DB, now time.Time) (*Digest, error) {
+	start := now.Add(-Interval())
+	var last models.DigestRun
+	err := db.Order("period_end DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	if last.ID != uuid.Nil {
+		start = last.PeriodEnd
+	}
+	return buildDigest(db, start, now)
+}
+
+// buildDigest collects the blogs created in a period and the events starting
+// within two weeks of its end
+func buildDigest(db *gorm.DB, start, now time.Time) (*Digest, error) {
+	digest := &Digest{PeriodStart: start, PeriodEnd: now}
+	if err := db.Preload("Author").
+		Where("created_at > ? AND created_at <= ?", digest.PeriodStart, digest.PeriodEnd).
+		Order("created_at").
+		Find(&digest.Blogs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("status = ? AND starts_at > ? AND starts_at <= ?",
+		models.EventStatusScheduled, now, now.Add(upcomingWindow)).
+		Order("starts_at").
+		Find(&digest.Events).Error; err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// Message returns the digest as an email to one subscriber
+func (d *Digest) Message(subscriber models.Subscriber) (mailer.Message, error) {
+	loc := location()
+	data := digestData{
+		Name:        subscriber.Name,
+		Unsubscribe: SiteLink("/newsletter/unsubscribe/" + subscriber.UnsubscribeToken),
+	}
+	for _, blog := range d.Blogs {
+		data.Blogs = append(data.Blogs, digestItem{
+			Title:   blog.Title,
+			Summary: blog.Description,
+			Meta:    "by " + blog.Author.Name,
+			Link:    SiteLink("/blogs/" + blog.ID.String()),
+		})
+	}
+	for _, event := range d.Events {
+		meta := event.StartsAt.In(loc).Format("Mon 2 Jan, 15:04")
+		if event.Location != "" {
+			meta += ", " + event.Location
+		}
+		data.Events = append(data.Events, digestItem{
+			Title: event.Title,
+			Meta:  meta,
+			Link:  SiteLink("/events/" + event.ID.String()),
+		})
+	}
+
+	var text, html bytes.Buffer
+	if err := digestText.Execute(&text, data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := digestHTML.Execute(&html, data); err != nil {
+		return mailer.Message{}, err
+	}
+
+	subject := "Avions Club: coming up"
+	if len(d.Blogs) > 0 {
+		subject = "Avions Club: " + d.Blogs[len(d.Blogs)-1].Title
+		if len(d.Blogs) > 1 {
+			subject += " and " + strconv.Itoa(len(d.Blogs)-1) + " more"
+		}
+	}
+	return mailer.Message{
+		To:      subscriber.Email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{"List-Unsubscribe": "<" + data.Unsubscribe + ">"},
+	}, nil
+}
+
+// SendDigest emails the digest to every confirmed subscriber. The run is
+// recorded first and each subscriber is marked once mailed, so a run that
+// was interrupted, e.g. by a restart, is resumed with the subscribers it had
+// not reached instead of starting over. When there is nothing to send the
+// run is still recorded, so the next digest does not repeat the period.
+// Delivery failures are logged and counted rather than returned; ErrDisabled
+// is returned when the newsletter is not configured.
+func SendDigest(db *gorm.DB, m mailer.Mailer, now time.Time) (*models.DigestRun, error) {
+	if !enabled {
+		return nil, ErrDisabled
+	}
+	sending.Lock()
+	defer sending.Unlock()
+
+	var run models.DigestRun
+	if err := db.Where("in_progress = ?", true).Order("period_end").Limit(1).Find(&run).Error; err != nil {
+		return nil, err
+	}
+	var digest *Digest
+	var err error
+	if run.ID != uuid.Nil {
+		digest, err = buildDigest(db, run.PeriodStart, run.PeriodEnd)
+	} else {
+		digest, err = BuildDigest(db, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if run.ID == uuid.Nil {
+		run = models.DigestRun{
+			PeriodStart: digest.PeriodStart,
+			PeriodEnd:   digest.PeriodEnd,
+			Blogs:       len(digest.Blogs),
+			Events:      len(digest.Events),
+			InProgress:  !digest.Empty(),
+		}
+		if err := db.Create(&run).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for run.InProgress {
+		var subscribers []models.Subscriber
+		if err := db.Where("status = ?", models.SubscriberStatusConfirmed).
+			Where("last_digest_id IS NULL OR last_digest_id <> ?", run.ID).
+			Order("created_at").
+			Limit(digestBatch).
+			Find(&subscribers).Error; err != nil {
+			return nil, err
+		}
+		run.InProgress = len(subscribers) > 0
+
+		for _, subscriber := range subscribers {
+			msg, err := digest.Message(subscriber)
+			if err == nil {
+				err = m.Send(msg)
+			}
+			counter := "recipients"
+			if err != nil {
+				log.Printf("Error sending digest to %s: %v", subscriber.Email, err)
+				counter = "failures"
+				run.Failures++
+			} else {
+				run.Recipients++
+			}
+			// Failed deliveries are not retried, so the subscriber is marked
+			// either way
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&subscriber).Update("last_digest_id", run.ID).Error; err != nil {
+					return err
+				}
+				return tx.Model(&run).Update(counter, gorm.Expr(counter+" + 1")).Error
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := db.Model(&run).Update("in_progress", false).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// StartDigest sends the digest in the background whenever the last one is
+// older than Interval, checking every checkEvery. It does nothing while the
+// newsletter is disabled.
+func StartDigest(db *gorm.DB, checkEvery time.Duration) {
+	if !enabled {
+		return
+	}
+	go func() {
+		for {
+			var last models.DigestRun
+			err := db.Order("period_end DESC").Limit(1).Find(&last).Error
+			switch {
+			case err != nil:
+				log.Printf("Error checking the last digest: %v", err)
+			case last.ID == uuid.Nil:
+				// The first digest waits a full interval instead of going out on deploy
+				if err := db.Create(&models.DigestRun{PeriodStart: time.Now(), PeriodEnd: time.Now()}).Error; err != nil {
+					log.Printf("Error starting the digest schedule: %v", err)
+				}
+			case last.InProgress || time.Since(last.PeriodEnd) >= Interval():
+				run, err := SendDigest(db, mailer.Default, time.Now())
+				if err != nil {
+					log.Printf("Error sending the digest: %v", err)
+				} else {
+					log.Printf("Sent the digest to %d subscribers (%d failed)", run.Recipients, run.Failures)
+				}
+			}
+			time.Sleep(checkEvery)
+		}
+	}()
+}
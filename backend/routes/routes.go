@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"time"
+
 	"avions-club/backend/handlers"
 	"avions-club/backend/middleware"
 
@@ -15,34 +17,222 @@ func SetupRoutes(r *gin.Engine) {
 	// Public routes
 	r.GET("/api/members", handlers.GetMembers)
 	r.GET("/api/members/:id", handlers.GetMember)
+	r.GET("/api/members/:id/blogs", handlers.GetMemberBlogs)
+	r.GET("/api/alumni", handlers.GetAlumni)
 	r.GET("/api/projects", handlers.GetProjects)
+	r.GET("/api/projects/popular", handlers.GetPopularProjects)
+	r.GET("/api/projects/:id/stats", handlers.GetProjectStats)
 	r.GET("/api/projects/:id", handlers.GetProject)
 	r.GET("/api/blogs", handlers.GetBlogs)
 	r.GET("/api/blogs/:id", handlers.GetBlog)
+	r.GET("/api/blogs/:id/comments", handlers.GetBlogComments)
+	r.GET("/api/blogs/popular", handlers.GetPopularBlogs)
+	r.GET("/api/blogs/:id/stats", handlers.GetBlogStats)
+	r.GET("/api/feed.rss", handlers.GetBlogFeed)
+	r.GET("/api/feed.atom", handlers.GetBlogFeed)
+	r.GET("/api/feed/tags/:tag", handlers.GetTagFeed)
+	r.GET("/api/feed/authors/:id", handlers.GetAuthorFeed)
+	r.GET("/api/events", handlers.GetEvents)
+	r.GET("/api/events/:id", handlers.GetEvent)
+	r.GET("/api/events/:id/calendar.ics", handlers.GetEventCalendar)
+	r.GET("/api/calendar.ics", handlers.GetCalendar)
+	r.GET("/api/calendar/tags/:tag", handlers.GetTagCalendar)
+	r.GET("/api/flights", handlers.GetFlightLogs)
+	r.GET("/api/flights/:id", handlers.GetFlightLog)
+	r.GET("/api/projects/:id/flight-stats", handlers.GetProjectFlightStats)
+	r.GET("/api/members/:id/flight-stats", handlers.GetMemberFlightStats)
+	r.GET("/api/sponsors", handlers.GetSponsors)
+	r.GET("/api/sponsors/:id", handlers.GetSponsor)
+	r.GET("/api/competitions", handlers.GetCompetitions)
+	r.GET("/api/competitions/:id", handlers.GetCompetition)
+	r.GET("/api/achievements", handlers.GetAchievements)
+	r.GET("/api/achievements/:id", handlers.GetAchievement)
+	r.GET("/api/albums", handlers.GetAlbums)
+	r.GET("/api/albums/:id", handlers.GetAlbum)
 	r.GET("/api/search", handlers.Search)
 
+	// Membership applications (public, rate limited against spam)
+	r.POST("/api/applications", middleware.RateLimit(5, time.Hour), handlers.SubmitApplication)
+
+	// Blog comments (public, rate limited against spam)
+	r.POST("/api/blogs/:id/comments", middleware.RateLimit(5, 10*time.Minute), handlers.CreateComment)
+
+	// Reactions (public, rate limited)
+	reactions := r.Group("/api", middleware.RateLimit(30, time.Minute))
+	reactions.POST("/blogs/:id/reactions", handlers.ReactToBlog)
+	reactions.DELETE("/blogs/:id/reactions/:kind", handlers.RemoveBlogReaction)
+	reactions.POST("/projects/:id/reactions", handlers.ReactToProject)
+	reactions.DELETE("/projects/:id/reactions/:kind", handlers.RemoveProjectReaction)
+
+	// Contact form (public, rate limited against spam)
+	r.POST("/api/contact", middleware.RateLimit(3, time.Hour), handlers.SubmitContact)
+
+	// Newsletter (public, rate limited against spam)
+	r.POST("/api/newsletter/subscribe", middleware.RateLimit(5, time.Hour), handlers.Subscribe)
+	r.POST("/api/newsletter/confirm/:token", handlers.ConfirmSubscription)
+	r.POST("/api/newsletter/unsubscribe/:token", handlers.Unsubscribe)
+
+	// Event RSVPs (public, rate limited against spam)
+	r.POST("/api/events/:id/rsvps", middleware.RateLimit(10, time.Hour), handlers.CreateRSVP)
+	r.DELETE("/api/events/:id/rsvps/:token", handlers.CancelRSVP)
+
 	// Auth routes (public)
 	r.POST("/api/auth/login", handlers.Login)
 
 	// Protected routes
 	protected := r.Group("")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(), middleware.Audit())
 	{
 		// Members
 		protected.POST("/api/members", handlers.CreateMember)
 		protected.PUT("/api/members/:id", handlers.UpdateMember)
+		protected.PATCH("/api/members/:id", handlers.PatchMember)
 		protected.DELETE("/api/members/:id", handlers.DeleteMember)
+		protected.POST("/api/members/:id/positions", handlers.AddMemberPosition)
+		protected.DELETE("/api/members/:id/positions/:positionId", handlers.DeleteMemberPosition)
+		protected.POST("/api/members/:id/certifications", handlers.AddMemberCertification)
+		protected.DELETE("/api/members/:id/certifications/:certificationId", handlers.DeleteMemberCertification)
 
 		// Projects
 		protected.POST("/api/projects", handlers.CreateProject)
 		protected.PUT("/api/projects/:id", handlers.UpdateProject)
+		protected.PATCH("/api/projects/:id", handlers.PatchProject)
 		protected.DELETE("/api/projects/:id", handlers.DeleteProject)
+		protected.POST("/api/projects/:id/telemetry", handlers.UploadTelemetry)
+		protected.DELETE("/api/projects/:id/telemetry/:telemetryId", handlers.DeleteTelemetry)
 
 		// Blogs
 		protected.POST("/api/blogs", handlers.CreateBlog)
 		protected.PUT("/api/blogs/:id", handlers.UpdateBlog)
+		protected.PATCH("/api/blogs/:id", handlers.PatchBlog)
 		protected.DELETE("/api/blogs/:id", handlers.DeleteBlog)
 
+		// Newsletter
+		protected.GET("/api/newsletter/subscribers", handlers.GetSubscribers)
+		protected.GET("/api/newsletter/digests", handlers.GetDigestRuns)
+		protected.POST("/api/newsletter/digests", handlers.SendDigest)
+
+		// Contact inbox
+		protected.GET("/api/contact/messages", handlers.GetContactMessages)
+		protected.GET("/api/contact/messages/:id", handlers.GetContactMessage)
+		protected.PUT("/api/contact/messages/:id/status", handlers.UpdateContactStatus)
+		protected.POST("/api/contact/messages/:id/forward", handlers.ForwardContactMessage)
+		protected.DELETE("/api/contact/messages/:id", handlers.DeleteContactMessage)
+
+		// Webhooks
+		protected.GET("/api/webhooks", handlers.GetWebhooks)
+		protected.GET("/api/webhooks/:id", handlers.GetWebhook)
+		protected.POST("/api/webhooks", handlers.CreateWebhook)
+		protected.PUT("/api/webhooks/:id", handlers.UpdateWebhook)
+		protected.PATCH("/api/webhooks/:id", handlers.PatchWebhook)
+		protected.DELETE("/api/webhooks/:id", handlers.DeleteWebhook)
+		protected.POST("/api/webhooks/:id/ping", handlers.PingWebhook)
+		protected.GET("/api/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+		protected.GET("/api/webhooks/:id/deliveries/:deliveryId", handlers.GetWebhookDelivery)
+		protected.POST("/api/webhooks/:id/deliveries/:deliveryId/redeliver", handlers.RedeliverWebhook)
+
+		// Audit log
+		protected.GET("/api/audit", handlers.GetAuditLogs)
+		protected.GET("/api/audit/export", handlers.ExportAuditLogs)
+
+		// Domain event outbox
+		protected.GET("/api/outbox", handlers.GetOutboxEvents)
+		protected.POST("/api/outbox/:id/retry", handlers.RetryOutboxEvent)
+
+		// Comment moderation
+		protected.GET("/api/comments", handlers.GetComments)
+		protected.PUT("/api/comments/:id/status", handlers.UpdateCommentStatus)
+		protected.DELETE("/api/comments/:id", handlers.DeleteComment)
+
+		// Events
+		protected.POST("/api/events", handlers.CreateEvent)
+		protected.PUT("/api/events/:id", handlers.UpdateEvent)
+		protected.PATCH("/api/events/:id", handlers.PatchEvent)
+		protected.DELETE("/api/events/:id", handlers.DeleteEvent)
+		protected.GET("/api/events/:id/rsvps", handlers.GetEventRSVPs)
+		protected.POST("/api/events/:id/rsvps/:rsvpId/check-in", handlers.CheckInRSVP)
+
+		// Flight logs
+		protected.POST("/api/flights", handlers.CreateFlightLog)
+		protected.POST("/api/flights/import", handlers.ImportFlightLogs)
+		protected.PUT("/api/flights/:id", handlers.UpdateFlightLog)
+		protected.PATCH("/api/flights/:id", handlers.PatchFlightLog)
+		protected.DELETE("/api/flights/:id", handlers.DeleteFlightLog)
+
+		// Sponsors
+		protected.POST("/api/sponsors", handlers.CreateSponsor)
+		protected.PUT("/api/sponsors/:id", handlers.UpdateSponsor)
+		protected.PATCH("/api/sponsors/:id", handlers.PatchSponsor)
+		protected.DELETE("/api/sponsors/:id", handlers.DeleteSponsor)
+
+		// Competitions and achievements
+		protected.POST("/api/competitions", handlers.CreateCompetition)
+		protected.PUT("/api/competitions/:id", handlers.UpdateCompetition)
+		protected.PATCH("/api/competitions/:id", handlers.PatchCompetition)
+		protected.DELETE("/api/competitions/:id", handlers.DeleteCompetition)
+		protected.POST("/api/achievements", handlers.CreateAchievement)
+		protected.PUT("/api/achievements/:id", handlers.UpdateAchievement)
+		protected.PATCH("/api/achievements/:id", handlers.PatchAchievement)
+		protected.DELETE("/api/achievements/:id", handlers.DeleteAchievement)
+
+		// Photo albums
+		protected.POST("/api/albums", handlers.CreateAlbum)
+		protected.PUT("/api/albums/:id", handlers.UpdateAlbum)
+		protected.PATCH("/api/albums/:id", handlers.PatchAlbum)
+		protected.DELETE("/api/albums/:id", handlers.DeleteAlbum)
+		protected.POST("/api/albums/:id/photos", handlers.AddPhotos)
+		protected.POST("/api/albums/:id/photos/upload", handlers.UploadPhotos)
+		protected.PUT("/api/albums/:id/photos/order", handlers.ReorderPhotos)
+		protected.PATCH("/api/albums/:id/photos/:photoId", handlers.PatchPhoto)
+		protected.DELETE("/api/albums/:id/photos/:photoId", handlers.DeletePhoto)
+
+		// Inventory
+		protected.GET("/api/inventory", handlers.GetInventory)
+		protected.GET("/api/inventory/low-stock", handlers.GetLowStock)
+		protected.GET("/api/inventory/checkouts", handlers.GetCheckouts)
+		protected.POST("/api/inventory/checkouts/:checkoutId/return", handlers.ReturnCheckout)
+		protected.GET("/api/inventory/:id", handlers.GetInventoryItem)
+		protected.POST("/api/inventory", handlers.CreateInventoryItem)
+		protected.PUT("/api/inventory/:id", handlers.UpdateInventoryItem)
+		protected.PATCH("/api/inventory/:id", handlers.PatchInventoryItem)
+		protected.DELETE("/api/inventory/:id", handlers.DeleteInventoryItem)
+		protected.POST("/api/inventory/:id/checkouts", handlers.CheckoutInventoryItem)
+
+		// Membership applications
+		protected.GET("/api/applications", handlers.GetApplications)
+		protected.GET("/api/applications/:id", handlers.GetApplication)
+		protected.PUT("/api/applications/:id/status", handlers.UpdateApplicationStatus)
+		protected.POST("/api/applications/:id/approve", handlers.ApproveApplication)
+
+		// Trash
+		protected.GET("/api/trash/members", handlers.GetTrashedMembers)
+		protected.POST("/api/trash/members/:id/restore", handlers.RestoreMember)
+		protected.DELETE("/api/trash/members/:id", handlers.PurgeMember)
+		protected.GET("/api/trash/projects", handlers.GetTrashedProjects)
+		protected.POST("/api/trash/projects/:id/restore", handlers.RestoreProject)
+		protected.DELETE("/api/trash/projects/:id", handlers.PurgeProject)
+		protected.GET("/api/trash/blogs", handlers.GetTrashedBlogs)
+		protected.POST("/api/trash/blogs/:id/restore", handlers.RestoreBlog)
+		protected.DELETE("/api/trash/blogs/:id", handlers.PurgeBlog)
+		protected.GET("/api/trash/events", handlers.GetTrashedEvents)
+		protected.POST("/api/trash/events/:id/restore", handlers.RestoreEvent)
+		protected.DELETE("/api/trash/events/:id", handlers.PurgeEvent)
+		protected.GET("/api/trash/flights", handlers.GetTrashedFlightLogs)
+		protected.POST("/api/trash/flights/:id/restore", handlers.RestoreFlightLog)
+		protected.DELETE("/api/trash/flights/:id", handlers.PurgeFlightLog)
+		protected.GET("/api/trash/inventory", handlers.GetTrashedInventoryItems)
+		protected.POST("/api/trash/inventory/:id/restore", handlers.RestoreInventoryItem)
+		protected.DELETE("/api/trash/inventory/:id", handlers.PurgeInventoryItem)
+		protected.GET("/api/trash/sponsors", handlers.GetTrashedSponsors)
+		protected.POST("/api/trash/sponsors/:id/restore", handlers.RestoreSponsor)
+		protected.DELETE("/api/trash/sponsors/:id", handlers.PurgeSponsor)
+		protected.GET("/api/trash/competitions", handlers.GetTrashedCompetitions)
+		protected.POST("/api/trash/competitions/:id/restore", handlers.RestoreCompetition)
+		protected.DELETE("/api/trash/competitions/:id", handlers.PurgeCompetition)
+		protected.GET("/api/trash/albums", handlers.GetTrashedAlbums)
+		protected.POST("/api/trash/albums/:id/restore", handlers.RestoreAlbum)
+		protected.DELETE("/api/trash/albums/:id", handlers.PurgeAlbum)
+
 		// Storage
 		protected.POST("/api/storage/upload", handlers.UploadFile)
 		protected.DELETE("/api/storage/:bucket/:filename", handlers.DeleteFile)
@@ -40,7 +40,7 @@ echo "Starting API Tests..."
 # Get admin token
 echo -e "\n${BLUE}1. Testing Authentication${NC}"
 ADMIN_PASSWORD=$(grep ADMIN_PASSWORD .env | cut -d '=' -f2 | tr -d '"' | tr -d '\r')
-AUTH_RESPONSE=$(call_api "POST" "/api/auth/login" "{\"password\": \"$ADMIN_PASSWORD\"}")
+AUTH_RESPONSE=$(call_api "POST" "/api/auth/login" "{\"name\": \"test script\", \"password\": \"$ADMIN_PASSWORD\"}")
 TOKEN=$(echo "$AUTH_RESPONSE" | grep -o '"token":"[^"]*"' | cut -d'"' -f4)
 
 if [ -z "$TOKEN" ]; then
@@ -214,16 +214,43 @@ func UploadFile(file *multipart.FileHeader, filename string) (string, error) {
 	}
 
 	// Generate public URL
-	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s",
-		os.Getenv("SUPABASE_URL"),
-		bucket,
-		cleanFilename,
-	)
+	publicURL := PublicURL(bucket, cleanFilename)
 
 	fmt.Printf("Generated URL: %s\n", publicURL)
 	return publicURL, nil
 }
 
+// PublicURL returns the public URL of an object in one of our buckets
+func PublicURL(bucket, filename string) string {
+	return publicURLPrefix(bucket) + filename
+}
+
+// IsPublicURL reports whether url points at an object in the given bucket
+func IsPublicURL(bucket, url string) bool {
+	if os.Getenv("SUPABASE_URL") == "" {
+		return false
+	}
+	prefix := publicURLPrefix(bucket)
+	return strings.HasPrefix(url, prefix) && len(url) > len(prefix) && !strings.Contains(url[len(prefix):], "/")
+}
+
+// ObjectFromURL returns the bucket and filename of one of our public URLs
+func ObjectFromURL(url string) (bucket, filename string, ok bool) {
+	for _, bucket := range []string{"images", "markdown"} {
+		if IsPublicURL(bucket, url) {
+			return bucket, strings.TrimPrefix(url, publicURLPrefix(bucket)), true
+		}
+	}
+	return "", "", false
+}
+
+func publicURLPrefix(bucket string) string {
+	return fmt.Sprintf("%s/storage/v1/object/public/%s/",
+		strings.TrimRight(os.Getenv("SUPABASE_URL"), "/"),
+		bucket,
+	)
+}
+
 func DeleteFile(bucket, filename string) error {
 	if bucket != "images" && bucket != "markdown" {
 		return fmt.Errorf("invalid bucket: %s", bucket)
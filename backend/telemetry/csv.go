@@ -0,0 +1,203 @@
+package telemetry
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics a CSV column can hold
+const (
+	colTime      = "time"
+	colAltitude  = "altitude"
+	colSpeed     = "speed"
+	colVoltage   = "voltage"
+	colRemaining = "remaining"
+)
+
+// csvColumn describes a known CSV header: the metric it holds and the factor
+// that converts its values to seconds, metres, metres per second or volts
+type csvColumn struct {
+	metric string
+	scale  float64
+}
+
+// csvColumns maps normalized header names, as exported by ground stations
+// and log viewers, to the metrics they hold
+var csvColumns = map[string]csvColumn{
+	"time":                        {colTime, 1},
+	"times":                       {colTime, 1},
+	"timestamp":                   {colTime, 1},
+	"timeus":                      {colTime, 1e-6},
+	"timeusec":                    {colTime, 1e-6},
+	"timestampus":                 {colTime, 1e-6},
+	"timems":                      {colTime, 1e-3},
+	"timebootms":                  {colTime, 1e-3},
+	"globalpositioninttimebootms": {colTime, 1e-3},
+
+	"alt":                          {colAltitude, 1},
+	"altm":                         {colAltitude, 1},
+	"altitude":                     {colAltitude, 1},
+	"altitudem":                    {colAltitude, 1},
+	"relalt":                       {colAltitude, 1},
+	"relhomealt":                   {colAltitude, 1},
+	"posrelhomealt":                {colAltitude, 1},
+	"relativealt":                  {colAltitude, 1e-3},
+	"globalpositionintrelativealt": {colAltitude, 1e-3},
+
+	"speed":             {colSpeed, 1},
+	"speedms":           {colSpeed, 1},
+	"groundspeed":       {colSpeed, 1},
+	"gspd":              {colSpeed, 1},
+	"gpsspd":            {colSpeed, 1},
+	"vfrhudgroundspeed": {colSpeed, 1},
+	"speedkmh":          {colSpeed, 1 / 3.6},
+	"speedkph":          {colSpeed, 1 / 3.6},
+
+	"voltage":                 {colVoltage, 1},
+	"voltagev":                {colVoltage, 1},
+	"volt":                    {colVoltage, 1},
+	"volts":                   {colVoltage, 1},
+	"vbat":                    {colVoltage, 1},
+	"batvolt":                 {colVoltage, 1},
+	"batteryvoltage":          {colVoltage, 1},
+	"voltagemv":               {colVoltage, 1e-3},
+	"voltagebattery":          {colVoltage, 1e-3},
+	"sysstatusvoltagebattery": {colVoltage, 1e-3},
+
+	"remaining":                 {colRemaining, 1},
+	"rempct":                    {colRemaining, 1},
+	"batrempct":                 {colRemaining, 1},
+	"batterypercent":            {colRemaining, 1},
+	"batteryremaining":          {colRemaining, 1},
+	"sysstatusbatteryremaining": {colRemaining, 1},
+}
+
+// timestampLayouts are the formats accepted for absolute times
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// ParseCSV summarises a telemetry CSV export with one sample per row. A time
+// column is required; it may hold seconds, a Unix timestamp or an RFC 3339
+// time. Columns are recognised by common names such as "TimeUS", "Alt",
+// "GPS.Spd" or "SYS_STATUS.voltage_battery" and other columns are ignored.
+func ParseCSV(r io.Reader) (*Summary, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoData
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	scales := make(map[string]float64)
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		column, ok := csvColumns[normalizeHeader(name)]
+		if !ok {
+			continue
+		}
+		if _, seen := columns[column.metric]; !seen {
+			columns[column.metric] = i
+			scales[column.metric] = column.scale
+		}
+	}
+	if _, ok := columns[colTime]; !ok {
+		return nil, errors.New("missing a time column")
+	}
+
+	c := collector{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(metric string) (float64, bool) {
+			i, ok := columns[metric]
+			if !ok || i >= len(record) {
+				return 0, false
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil || math.IsNaN(v) {
+				return 0, false
+			}
+			return v * scales[metric], true
+		}
+
+		t, ok := value(colTime)
+		if ok && t > 1e9 {
+			// Large values are Unix timestamps rather than time since boot
+			c.absolute = true
+		}
+		if !ok {
+			if columns[colTime] >= len(record) {
+				continue
+			}
+			at, parsed := parseTimestamp(record[columns[colTime]])
+			if !parsed {
+				continue
+			}
+			t = float64(at.UnixNano()) / 1e9
+			c.absolute = true
+		}
+
+		if alt, ok := value(colAltitude); ok {
+			c.altitude(t, alt)
+		}
+		if speed, ok := value(colSpeed); ok {
+			c.speed(t, speed)
+		}
+		if voltage, ok := value(colVoltage); ok && voltage > 0 {
+			var remaining *int
+			if percent, ok := value(colRemaining); ok && percent >= 0 {
+				rounded := int(percent + 0.5)
+				remaining = &rounded
+			}
+			c.batteryLevel(t, voltage, remaining)
+		}
+	}
+
+	return c.summary()
+}
+
+// parseTimestamp reads an absolute time from a CSV cell
+func parseTimestamp(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeHeader lowercases a header and drops everything but letters and
+// digits, so "GPS.Spd" becomes "gpsspd"
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+// Package telemetry summarises flight-controller logs into the metrics shown
+// on project pages: altitude, speed, flight time and the battery curve.
+package telemetry
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// maxBatteryPoints caps the battery curve so stored summaries stay small
+const maxBatteryPoints = 120
+
+// ErrNoData is returned when a log holds none of the metrics we summarise
+var ErrNoData = errors.New("log contains no usable telemetry")
+
+// BatteryPoint is one sample of the battery curve. Seconds counts from the
+// start of the log.
+type BatteryPoint struct {
+	Seconds          float64 `json:"seconds"`
+	Voltage          float64 `json:"voltage"`
+	RemainingPercent *int    `json:"remainingPercent,omitempty"`
+}
+
+// Summary holds the metrics of a log. Metrics the log does not record are nil.
+type Summary struct {
+	StartedAt               *time.Time     `json:"startedAt,omitempty"`
+	FlightTimeSeconds       float64        `json:"flightTimeSeconds"`
+	MaxAltitudeMeters       *float64       `json:"maxAltitudeMeters"`
+	MaxSpeedMetersPerSecond *float64       `json:"maxSpeedMetersPerSecond"`
+	Samples                 int            `json:"samples"`
+	Battery                 []BatteryPoint `json:"battery"`
+}
+
+// collector accumulates samples while a log is read. Times are in seconds,
+// either since the Unix epoch or since an arbitrary boot time.
+type collector struct {
+	first, last float64
+	seen        bool
+	absolute    bool
+
+	samples  int
+	maxAlt   *float64
+	maxSpeed *float64
+	battery  []BatteryPoint
+
+	armedSeen  bool
+	armed      bool
+	armedSince float64
+	armedTotal float64
+}
+
+// observe records that a sample was taken at t
+func (c *collector) observe(t float64) {
+	if !c.seen {
+		c.first, c.last, c.seen = t, t, true
+	}
+	c.first = math.Min(c.first, t)
+	c.last = math.Max(c.last, t)
+}
+
+func (c *collector) altitude(t, meters float64) {
+	c.observe(t)
+	c.samples++
+	if c.maxAlt == nil || meters > *c.maxAlt {
+		c.maxAlt = &meters
+	}
+}
+
+func (c *collector) speed(t, metersPerSecond float64) {
+	c.observe(t)
+	c.samples++
+	if c.maxSpeed == nil || metersPerSecond > *c.maxSpeed {
+		c.maxSpeed = &metersPerSecond
+	}
+}
+
+func (c *collector) batteryLevel(t, voltage float64, remaining *int) {
+	c.observe(t)
+	c.samples++
+	c.battery = append(c.battery, BatteryPoint{Seconds: t, Voltage: voltage, RemainingPercent: remaining})
+}
+
+// arming records the armed state reported at t. Flight time is the total time
+// spent armed when the log reports it.
+func (c *collector) arming(t float64, armed bool) {
+	c.observe(t)
+	c.armedSeen = true
+	if armed && !c.armed {
+		c.armedSince = t
+	}
+	if !armed && c.armed {
+		c.armedTotal += t - c.armedSince
+	}
+	c.armed = armed
+}
+
+// summary returns the metrics collected so far
+func (c *collector) summary() (*Summary, error) {
+	if c.samples == 0 {
+		return nil, ErrNoData
+	}
+
+	summary := &Summary{
+		Samples:                 c.samples,
+		MaxAltitudeMeters:       round(c.maxAlt),
+		MaxSpeedMetersPerSecond: round(c.maxSpeed),
+		Battery:                 []BatteryPoint{},
+	}
+	if c.absolute {
+		startedAt := time.Unix(0, int64(c.first*float64(time.Second))).UTC()
+		summary.StartedAt = &startedAt
+	}
+
+	flightTime := c.last - c.first
+	if c.armedSeen {
+		flightTime = c.armedTotal
+		if c.armed {
+			flightTime += c.last - c.armedSince
+		}
+	}
+	summary.FlightTimeSeconds = math.Round(flightTime*10) / 10
+
+	step := 1.0
+	if len(c.battery) > maxBatteryPoints {
+		step = float64(len(c.battery)-1) / float64(maxBatteryPoints-1)
+	}
+	for i := 0.0; int(math.Round(i)) < len(c.battery); i += step {
+		point := c.battery[int(math.Round(i))]
+		point.Seconds = math.Round((point.Seconds-c.first)*10) / 10
+		point.Voltage = math.Round(point.Voltage*100) / 100
+		summary.Battery = append(summary.Battery, point)
+	}
+	return summary, nil
+}
+
+// round rounds a metric to centimetres or centimetres per second
+func round(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	rounded := math.Round(*v*100) / 100
+	return &rounded
+}
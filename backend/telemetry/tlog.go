@@ -0,0 +1,172 @@
+package telemetry
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// MAVLink messages read from telemetry logs
+const (
+	msgHeartbeat         = 0
+	msgSysStatus         = 1
+	msgGlobalPositionInt = 33
+	msgVFRHUD            = 74
+)
+
+// crcExtra seeds the checksum of the messages we decode, as defined by the
+// MAVLink common message set
+var crcExtra = map[uint32]byte{
+	msgHeartbeat:         50,
+	msgSysStatus:         124,
+	msgGlobalPositionInt: 104,
+	msgVFRHUD:            20,
+}
+
+// payloadLength is the full payload size of the messages we decode. MAVLink 2
+// trims trailing zero bytes, which are restored before decoding.
+var payloadLength = map[uint32]int{
+	msgHeartbeat:         9,
+	msgSysStatus:         31,
+	msgGlobalPositionInt: 28,
+	msgVFRHUD:            20,
+}
+
+// mavTypeGCS is the MAV_TYPE of ground stations, whose heartbeats are ignored
+const mavTypeGCS = 6
+
+// mavModeFlagArmed is the base_mode bit set while the vehicle is armed
+const mavModeFlagArmed = 0x80
+
+// Timestamps outside 2000-2100 mean we are not at a record boundary
+const (
+	minTimestampMicros = 946684800 * 1e6
+	maxTimestampMicros = 4102444800 * 1e6
+)
+
+// packet is a decoded MAVLink frame
+type packet struct {
+	systemID  byte
+	messageID uint32
+	payload   []byte
+}
+
+// ParseTLog summarises a MAVLink telemetry log as written by ground stations
+// such as Mission Planner and QGroundControl: each frame is preceded by a
+// big-endian timestamp in microseconds since the Unix epoch. Corrupt bytes
+// are skipped until the next valid frame.
+func ParseTLog(r io.Reader) (*Summary, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := collector{absolute: true}
+	var vehicle byte
+	for i := 0; i+8 < len(data); {
+		micros := binary.BigEndian.Uint64(data[i:])
+		if micros < minTimestampMicros || micros > maxTimestampMicros {
+			i++
+			continue
+		}
+		pkt, n, ok := decodeFrame(data[i+8:])
+		if !ok {
+			i++
+			continue
+		}
+		i += 8 + n
+
+		// Only follow the first vehicle heard; ground stations and other
+		// aircraft on the link are ignored
+		if pkt.systemID == 255 || (vehicle != 0 && pkt.systemID != vehicle) {
+			continue
+		}
+		t := float64(micros) / 1e6
+		p := pkt.payload
+
+		switch pkt.messageID {
+		case msgHeartbeat:
+			if p[4] == mavTypeGCS {
+				continue
+			}
+			vehicle = pkt.systemID
+			c.arming(t, p[6]&mavModeFlagArmed != 0)
+		case msgSysStatus:
+			millivolts := binary.LittleEndian.Uint16(p[14:])
+			if millivolts == 0 || millivolts == math.MaxUint16 {
+				continue
+			}
+			var remaining *int
+			if percent := int(int8(p[30])); percent >= 0 {
+				remaining = &percent
+			}
+			c.batteryLevel(t, float64(millivolts)/1000, remaining)
+		case msgGlobalPositionInt:
+			relativeAlt := int32(binary.LittleEndian.Uint32(p[16:]))
+			vx := float64(int16(binary.LittleEndian.Uint16(p[20:])))
+			vy := float64(int16(binary.LittleEndian.Uint16(p[22:])))
+			c.altitude(t, float64(relativeAlt)/1000)
+			c.speed(t, math.Hypot(vx, vy)/100)
+		case msgVFRHUD:
+			groundspeed := math.Float32frombits(binary.LittleEndian.Uint32(p[4:]))
+			if !math.IsNaN(float64(groundspeed)) {
+				c.speed(t, float64(groundspeed))
+			}
+		}
+	}
+
+	return c.summary()
+}
+
+// decodeFrame decodes the MAVLink 1 or 2 frame at the start of b and returns
+// its length. Frames of messages we decode must carry a valid checksum; other
+// frames are only checked for length.
+func decodeFrame(b []byte) (packet, int, bool) {
+	var pkt packet
+	var header, length int
+	switch {
+	case len(b) >= 8 && b[0] == 0xFE:
+		header = 6
+		length = header + int(b[1]) + 2
+		pkt.systemID = b[3]
+		pkt.messageID = uint32(b[5])
+	case len(b) >= 12 && b[0] == 0xFD:
+		header = 10
+		length = header + int(b[1]) + 2
+		if b[2]&0x01 != 0 {
+			length += 13 // signature
+		}
+		pkt.systemID = b[5]
+		pkt.messageID = uint32(b[7]) | uint32(b[8])<<8 | uint32(b[9])<<16
+	default:
+		return pkt, 0, false
+	}
+	if len(b) < length {
+		return pkt, 0, false
+	}
+
+	end := header + int(b[1])
+	extra, known := crcExtra[pkt.messageID]
+	if !known {
+		return pkt, length, true
+	}
+	if checksum(b[1:end], extra) != binary.LittleEndian.Uint16(b[end:]) {
+		return pkt, 0, false
+	}
+
+	pkt.payload = make([]byte, max(payloadLength[pkt.messageID], end-header))
+	copy(pkt.payload, b[header:end])
+	return pkt, length, true
+}
+
+// checksum computes the CRC-16/MCRF4XX of a frame, seeded with the
+// message's CRC extra byte
+func checksum(b []byte, extra byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, v := range append(b[:len(b):len(b)], extra) {
+		tmp := v ^ byte(crc)
+		tmp ^= tmp << 4
+		crc = crc>>8 ^ uint16(tmp)<<8 ^ uint16(tmp)<<3 ^ uint16(tmp)>>4
+	}
+	return crc
+}
@@ -207,7 +207,7 @@ echo "Getting admin token..."
 TOKEN_RESPONSE=$(curl -s -X POST "$BASE_URL/api/auth/login" \
     -H "Content-Type: application/json" \
     -d '{
-        "username": "admin",
+        "name": "test script",
         "password": "pass123"
     }')
 TOKEN=$(echo "$TOKEN_RESPONSE" | grep -o '"token":"[^"]*"' | cut -d'"' -f4)
@@ -0,0 +1,322 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"avions-club/backend/events"
+	"avions-club/backend/models"
+	"avions-club/backend/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultRetentionDays is how long deleted content stays restorable when
+// TRASH_RETENTION_DAYS is not set
+const defaultRetentionDays = 30
+
+// ErrInUse is returned when a trashed row is still referenced by other content
+var ErrInUse = errors.New("item is still referenced by other content")
+
+// fileColumns lists every column that holds the URL of a stored file. The
+// same upload can be used by several rows, e.g. as a member photo and in an
+// album, so a file is only removed once none of them points at it.
+var fileColumns = []struct {
+	model  any
+	column string
+}{
+	{&models.Member{}, "image_url"},
+	{&models.Project{}, "image_url"},
+	{&models.Project{}, "markdown_url"},
+	{&models.Blog{}, "markdown_url"},
+	{&models.Blog{}, "cover_url"},
+	{&models.Event{}, "image_url"},
+	{&models.InventoryItem{}, "image_url"},
+	{&models.Sponsor{}, "logo_url"},
+	{&models.Competition{}, "image_url"},
+	{&models.Album{}, "cover_url"},
+	{&models.Photo{}, "url"},
+}
+
+// fileInUse reports whether any row, including rows in the trash, still
+// refers to a stored file
+func fileInUse(db *gorm.DB, url string) (bool, error) {
+	for _, file := range fileColumns {
+		var count int64
+		if err := db.Unscoped().Model(file.model).
+			Where(file.column+" = ?", url).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	evidence, _ := json.Marshal([]string{url})
+	var count int64
+	err := db.Model(&models.Achievement{}).
+		Where("evidence_urls @> ?::jsonb", string(evidence)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Retention returns how long soft-deleted rows are kept before being purged
+func Retention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Purge permanently deletes a trashed row and the storage objects it owns
+// that no other row uses, and publishes the purge of blogs, projects, events
+// and members. Storage cleanup runs once the rows are gone and is best
+// effort: failures are logged, not returned.
+func Purge(db *gorm.DB, item models.Trashable) error {
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return purgeRows(tx, item)
+	}); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, url := range item.FileURLs() {
+		bucket, filename, ok := storage.ObjectFromURL(url)
+		if !ok || seen[url] {
+			continue
+		}
+		seen[url] = true
+		inUse, err := fileInUse(db, url)
+		if err != nil {
+			log.Printf("Error checking whether %s/%s is in use while purging: %v", bucket, filename, err)
+			continue
+		}
+		if inUse {
+			continue
+		}
+		if err := storage.DeleteFile(bucket, filename); err != nil {
+			log.Printf("Error deleting %s/%s while purging: %v", bucket, filename, err)
+		}
+	}
+	return nil
+}
+
+// purgeRows deletes a trashed row along with what belongs to it, after
+// checking that no other content still needs it
+func purgeRows(db *gorm.DB, item models.Trashable) error {
+	if member, ok := item.(*models.Member); ok {
+		var count int64
+		if err := db.Model(&models.BlogAuthor{}).
+			Where("member_id = ?", member.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrInUse
+		}
+		// Flight logs keep their pilot for the project's history, and so do
+		// logs in the trash until they are purged
+		if err := db.Unscoped().Model(&models.FlightLog{}).
+			Where("pilot_id = ?", member.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrInUse
+		}
+		// Equipment still out must come back first; returned checkouts
+		// are removed with the member
+		if err := db.Model(&models.InventoryCheckout{}).
+			Where("member_id = ? AND returned_at IS NULL AND consumed = ?", member.ID, false).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrInUse
+		}
+	}
+
+	// Checkouts keep their item; an item only goes to the trash once every
+	// unit is back, so its checkout history goes with it
+	if inventory, ok := item.(*models.InventoryItem); ok {
+		var count int64
+		if err := db.Model(&models.InventoryCheckout{}).
+			Where("item_id = ? AND returned_at IS NULL AND consumed = ?", inventory.ID, false).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrInUse
+		}
+		if err := db.Where("item_id = ?", inventory.ID).
+			Delete(&models.InventoryCheckout{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// Achievements and photos are removed with their competition or album;
+	// load them first so their files are cleaned up too
+	switch content := item.(type) {
+	case *models.Competition:
+		if err := db.Where("competition_id = ?", content.ID).
+			Find(&content.Achievements).Error; err != nil {
+			return err
+		}
+	case *models.Album:
+		if err := db.Where("album_id = ?", content.ID).
+			Find(&content.Photos).Error; err != nil {
+			return err
+		}
+	}
+	// The event names the byline and projects, which go with the row
+	_, purged, data, err := trashEvent(db, item)
+	if err != nil {
+		return err
+	}
+
+	// Databases created before the event_projects constraints cascaded still
+	// refuse to delete linked rows, so unlink them first
+	switch content := item.(type) {
+	case *models.Event:
+		if err := db.Exec("DELETE FROM event_projects WHERE event_id = ?", content.ID).Error; err != nil {
+			return err
+		}
+	case *models.Project:
+		if err := db.Exec("DELETE FROM event_projects WHERE project_id = ?", content.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Unscoped().Delete(item).Error; err != nil {
+		return err
+	}
+
+	// Views and reactions refer to content by type and ID, not a foreign key
+	var contentType string
+	var contentID uuid.UUID
+	switch content := item.(type) {
+	case *models.Blog:
+		contentType, contentID = models.ContentTypeBlog, content.ID
+	case *models.Project:
+		contentType, contentID = models.ContentTypeProject, content.ID
+	}
+	if contentType != "" {
+		for _, model := range []any{&models.ContentView{}, &models.Reaction{}} {
+			if err := db.Where("content_type = ? AND content_id = ?", contentType, contentID).
+				Delete(model).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if purged == "" {
+		return nil
+	}
+	return events.Publish(db, purged, data)
+}
+
+// Restore brings a trashed row back and publishes the restore of blogs,
+// projects, events and members
+func Restore(db *gorm.DB, item models.Trashable) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(item).Updates(map[string]any{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		restored, _, data, err := trashEvent(tx, item)
+		if err != nil || restored == "" {
+			return err
+		}
+		return events.Publish(tx, restored, data)
+	})
+}
+
+// trashEvent returns the names of the domain events for restoring and
+// purging a row and their payload, loading the relations the payload names.
+// Rows without domain events return empty names.
+func trashEvent(db *gorm.DB, item models.Trashable) (restored, purged string, data any, err error) {
+	switch content := item.(type) {
+	case *models.Blog:
+		if err := db.Preload("Member").
+			Where("blog_id = ?", content.ID).
+			Order("position").
+			Find(&content.Authors).Error; err != nil {
+			return "", "", nil, err
+		}
+		return events.BlogRestored, events.BlogPurged, events.NewBlogData(content), nil
+	case *models.Project:
+		return events.ProjectRestored, events.ProjectPurged, events.NewProjectData(content), nil
+	case *models.Event:
+		if err := db.Model(content).Association("Projects").Find(&content.Projects); err != nil {
+			return "", "", nil, err
+		}
+		return events.EventRestored, events.EventPurged, events.NewEventData(content), nil
+	case *models.Member:
+		return events.MemberRestored, events.MemberPurged, events.NewMemberData(content), nil
+	}
+	return "", "", nil, nil
+}
+
+// PurgeExpired purges every row that has been in the trash for longer than
+// the retention period and returns how many were removed
+func PurgeExpired(db *gorm.DB) int {
+	cutoff := time.Now().Add(-Retention())
+	// Blogs go first so their authors are no longer referenced
+	return purgeExpired[models.Blog](db, cutoff) +
+		purgeExpired[models.Event](db, cutoff) +
+		purgeExpired[models.FlightLog](db, cutoff) +
+		purgeExpired[models.InventoryItem](db, cutoff) +
+		purgeExpired[models.Sponsor](db, cutoff) +
+		purgeExpired[models.Competition](db, cutoff) +
+		purgeExpired[models.Album](db, cutoff) +
+		purgeExpired[models.Project](db, cutoff) +
+		purgeExpired[models.Member](db, cutoff)
+}
+
+func purgeExpired[T any, PT interface {
+	*T
+	models.Trashable
+}](db *gorm.DB, cutoff time.Time) int {
+	var items []T
+	if err := db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Find(&items).Error; err != nil {
+		log.Printf("Error listing expired trash: %v", err)
+		return 0
+	}
+
+	purged := 0
+	for i := range items {
+		err := Purge(db, PT(&items[i]))
+		if errors.Is(err, ErrInUse) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Error purging expired trash: %v", err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// StartPurger purges expired trash once at startup and then every interval
+func StartPurger(db *gorm.DB, interval time.Duration) {
+	go func() {
+		for {
+			if purged := PurgeExpired(db); purged > 0 {
+				log.Printf("Purged %d expired items from the trash", purged)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
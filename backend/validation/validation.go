@@ -0,0 +1,151 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"avions-club/backend/apierror"
+	"avions-club/backend/storage"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Register installs the custom rules on gin's validator and makes validation
+// errors report JSON field names instead of Go struct field names
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unexpected validator engine %T", binding.Validator.Engine())
+	}
+
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// storageurl=<bucket> only accepts public URLs of objects in our own bucket
+	return v.RegisterValidation("storageurl", func(fl validator.FieldLevel) bool {
+		return storage.IsPublicURL(fl.Param(), fl.Field().String())
+	})
+}
+
+// FieldErrors converts a binding error into per-field errors. It returns
+// false when err is not about individual fields, e.g. malformed JSON.
+func FieldErrors(err error) ([]apierror.FieldError, bool) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]apierror.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, apierror.FieldError{
+				Field:   fieldPath(fe),
+				Code:    code(fe),
+				Message: message(fe),
+			})
+		}
+		return fields, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []apierror.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type),
+		}}, true
+	}
+
+	return nil, false
+}
+
+// fieldPath strips the struct name from the namespace, e.g.
+// "BlogRequest.coAuthorIds[1]" becomes "coAuthorIds[1]"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+// code maps a validation tag to the machine-readable code sent to clients
+func code(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "required"
+	case "max", "lte":
+		if fe.Kind() == reflect.String {
+			return "too_long"
+		}
+		return "too_large"
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return "too_short"
+		}
+		return "too_small"
+	case "url", "http_url":
+		return "invalid_url"
+	case "storageurl":
+		return "invalid_storage_url"
+	case "oneof":
+		return "invalid_choice"
+	case "gtfield", "gtefield", "ltfield", "ltefield":
+		return "out_of_range"
+	case "email":
+		return "invalid_email"
+	default:
+		return "invalid"
+	}
+}
+
+// message builds a human-readable explanation of a failed rule
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max", "lte":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "storageurl":
+		return fmt.Sprintf("must be a file uploaded to the %s bucket", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "gtfield", "gtefield":
+		return fmt.Sprintf("must be after %s", lowerFirst(fe.Param()))
+	case "ltfield", "ltefield":
+		return fmt.Sprintf("must be before %s", lowerFirst(fe.Param()))
+	case "email":
+		return "must be a valid email address"
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+// lowerFirst turns a Go field name such as StartedAt into its JSON name
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
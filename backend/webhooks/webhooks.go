@@ -0,0 +1,440 @@
+// Package webhooks notifies outside services, such as a Discord channel or
+// the frontend's static rebuild hook, when content changes. Every event is
+// stored as a delivery per subscribed webhook and sent in the background, so
+// a slow or unreachable endpoint never holds up a request. Failed deliveries
+// are retried with exponential backoff.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"avions-club/backend/events"
+	"avions-club/backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventPing is sent on request to check that a webhook is reachable
+const EventPing = "ping"
+
+// AllEvents subscribes a webhook to every event
+const AllEvents = "*"
+
+// subscriberName is the name the dispatcher subscribes to the event bus
+// with
+const subscriberName = "webhooks"
+
+const (
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts = 8
+
+	// baseDelay is the wait after the first failed attempt; it doubles with
+	// every further attempt up to maxDelay
+	baseDelay = 30 * time.Second
+	maxDelay  = 6 * time.Hour
+
+	// requestTimeout bounds a single attempt
+	requestTimeout = 10 * time.Second
+
+	// claimLease keeps a claimed delivery from being claimed again while it
+	// is being sent; it must outlast requestTimeout
+	claimLease = 2 * time.Minute
+
+	// batchSize is how many due deliveries are sent at once
+	batchSize = 20
+
+	// maxResponseBody is how much of a response is kept in the delivery log
+	maxResponseBody = 2048
+
+	// maxDiscordContent is the longest message Discord accepts
+	maxDiscordContent = 2000
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// ErrNotStarted is returned when a delivery is requested before Start
+var ErrNotStarted = errors.New("webhook dispatcher is not running")
+
+// Envelope is the JSON body of a delivery. ID identifies the event and is
+// shared by all its deliveries, including redeliveries, so receivers can
+// drop duplicates.
+type Envelope struct {
+	ID        uuid.UUID `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// Subscribed reports whether a webhook receives an event
+func Subscribed(webhook models.Webhook, event string) bool {
+	return slices.Contains(webhook.Events, AllEvents) || slices.Contains(webhook.Events, event)
+}
+
+// Sign returns the X-Webhook-Signature header of a body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers
+// recompute the HMAC with the shared secret and reject old timestamps to
+// stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait before retrying after the given number
+// of failed attempts, with up to 20% jitter so retries do not bunch up
+func Backoff(attempts int) time.Duration {
+	delay := maxDelay
+	if attempts < 20 {
+		delay = min(baseDelay<<(attempts-1), maxDelay)
+	}
+	return delay + rand.N(delay/5+1)
+}
+
+// Dispatcher stores deliveries and sends them in the background
+type Dispatcher struct {
+	db     *gorm.DB
+	client *http.Client
+	wakeCh chan struct{}
+}
+
+// NewDispatcher returns a dispatcher using db. Call Run to send deliveries.
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		db: db,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			// A redirect is reported as the response rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		wakeCh: make(chan struct{}, 1),
+	}
+}
+
+// wake makes Run look for due deliveries without waiting for the next poll
+func (d *Dispatcher) wake() {
+	select {
+	case d.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// newDelivery returns a pending delivery of an envelope to a webhook, due now
+func newDelivery(webhook models.Webhook, envelope Envelope) (models.WebhookDelivery, error) {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	now := time.Now()
+	return models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       envelope.ID,
+		Event:         envelope.Event,
+		Payload:       payload,
+		Status:        models.DeliveryStatusPending,
+		NextAttemptAt: &now,
+	}, nil
+}
+
+// Dispatch queues a domain event for every active webhook subscribed to
+// it. Dispatching the same event again queues nothing new.
+func (d *Dispatcher) Dispatch(event events.Event) error {
+	var webhooks []models.Webhook
+	if err := d.db.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		return err
+	}
+
+	envelope := Envelope{ID: event.ID, Event: event.Name, CreatedAt: event.OccurredAt.UTC(), Data: event.Payload}
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !Subscribed(webhook, event.Name) {
+			continue
+		}
+		delivery, err := newDelivery(webhook, envelope)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := d.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "redelivery_of IS NULL"}}},
+		DoNothing:   true,
+	}).Create(&deliveries).Error; err != nil {
+		return err
+	}
+	d.wake()
+	return nil
+}
+
+// Ping queues a ping event for one webhook, whatever it subscribes to
+func (d *Dispatcher) Ping(webhook models.Webhook) (models.WebhookDelivery, error) {
+	delivery, err := newDelivery(webhook, Envelope{
+		ID:        uuid.New(),
+		Event:     EventPing,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]any{"webhookId": webhook.ID, "name": webhook.Name},
+	})
+	if err != nil {
+		return delivery, err
+	}
+	if err := d.db.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	d.wake()
+	return delivery, nil
+}
+
+// Redeliver queues a new delivery with the same event and payload as an
+// earlier one, leaving the earlier delivery's log untouched
+func (d *Dispatcher) Redeliver(original models.WebhookDelivery) (models.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryStatusPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+	}
+	if err := d.db.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	d.wake()
+	return delivery, nil
+}
+
+// claim picks the deliveries that are due and pushes their next attempt
+// past the lease, so other instances skip them while they are being sent
+func (d *Dispatcher) claim() ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryStatusPending, now).
+			Order("next_attempt_at").
+			Limit(batchSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(due))
+		for i, delivery := range due {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	return due, err
+}
+
+// discordBody turns an envelope into a Discord message, naming the event,
+// the title or name of the content and a link to it
+func discordBody(envelope Envelope) ([]byte, error) {
+	var data struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Name  string `json:"name"`
+	}
+	if raw, err := json.Marshal(envelope.Data); err == nil {
+		_ = json.Unmarshal(raw, &data)
+	}
+
+	entity, action, _ := strings.Cut(envelope.Event, ".")
+	content := fmt.Sprintf("**%s %s**", strings.ToUpper(entity[:1])+entity[1:], action)
+	if envelope.Event == EventPing {
+		content = "**Ping** from the Avions Club website"
+	}
+	if label := data.Title + data.Name; label != "" {
+		content += ": " + label
+	}
+	if site := strings.TrimRight(os.Getenv("SITE_URL"), "/"); site != "" && data.ID != "" && action != "deleted" && action != "purged" {
+		content += fmt.Sprintf("\n%s/%ss/%s", site, entity, data.ID)
+	}
+	if len(content) > maxDiscordContent {
+		content = content[:maxDiscordContent]
+	}
+	return json.Marshal(map[string]string{"content": content})
+}
+
+// send makes one attempt at a delivery and returns the response status and
+// the start of the response body
+func (d *Dispatcher) send(webhook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	if webhook.Format == models.WebhookFormatDiscord {
+		var envelope Envelope
+		if err := json.Unmarshal(delivery.Payload, &envelope); err != nil {
+			return 0, "", err
+		}
+		var err error
+		if body, err = discordBody(envelope); err != nil {
+			return 0, "", err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AvionsClub-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, time.Now().Unix(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(excerpt), fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, string(excerpt), nil
+}
+
+// deliver attempts a claimed delivery and records the outcome, scheduling a
+// retry after a failure until MaxAttempts is reached
+func (d *Dispatcher) deliver(delivery models.WebhookDelivery) {
+	start := time.Now()
+	updates := map[string]any{
+		"attempts":        delivery.Attempts + 1,
+		"last_attempt_at": start,
+		"response_status": nil,
+		"response_body":   "",
+		"error":           "",
+	}
+
+	var webhook models.Webhook
+	var status int
+	var excerpt string
+	err := d.db.First(&webhook, "id = ?", delivery.WebhookID).Error
+	disabled := err != nil || !webhook.Active
+	if disabled {
+		err = errors.New("webhook is disabled")
+	} else {
+		status, excerpt, err = d.send(webhook, delivery)
+	}
+	updates["duration_ms"] = time.Since(start).Milliseconds()
+	if status != 0 {
+		updates["response_status"] = status
+		updates["response_body"] = excerpt
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliveryStatusSucceeded
+		updates["next_attempt_at"] = nil
+	case disabled || delivery.Attempts+1 >= MaxAttempts:
+		updates["status"] = models.DeliveryStatusFailed
+		updates["next_attempt_at"] = nil
+		updates["error"] = err.Error()
+	default:
+		updates["next_attempt_at"] = time.Now().Add(Backoff(delivery.Attempts + 1))
+		updates["error"] = err.Error()
+	}
+
+	if err := d.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		log.Printf("Error recording webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// deliverDue sends every delivery that is due, a batch at a time
+func (d *Dispatcher) deliverDue() error {
+	for {
+		due, err := d.claim()
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(delivery)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// Run sends due deliveries every poll interval, or as soon as new ones are
+// queued. It never returns.
+func (d *Dispatcher) Run(poll time.Duration) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		if err := d.deliverDue(); err != nil {
+			log.Printf("Error sending webhooks: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-d.wakeCh:
+		}
+	}
+}
+
+// defaultDispatcher is the dispatcher used by the package-level functions
+var defaultDispatcher *Dispatcher
+
+// Start creates the default dispatcher, subscribes it to every domain event
+// and sends deliveries in the background, checking for retries that have
+// come due every poll interval. Call it before events.Start.
+func Start(db *gorm.DB, poll time.Duration) {
+	defaultDispatcher = NewDispatcher(db)
+	events.Subscribe(subscriberName, events.All, defaultDispatcher.Dispatch)
+	go defaultDispatcher.Run(poll)
+}
+
+// Ping queues a ping for a webhook with the default dispatcher
+func Ping(webhook models.Webhook) (models.WebhookDelivery, error) {
+	if defaultDispatcher == nil {
+		return models.WebhookDelivery{}, ErrNotStarted
+	}
+	return defaultDispatcher.Ping(webhook)
+}
+
+// Redeliver queues a delivery again with the default dispatcher
+func Redeliver(original models.WebhookDelivery) (models.WebhookDelivery, error) {
+	if defaultDispatcher == nil {
+		return models.WebhookDelivery{}, ErrNotStarted
+	}
+	return defaultDispatcher.Redeliver(original)
+}